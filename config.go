@@ -0,0 +1,491 @@
+package cera
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/xxxmailk/cera/http"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables overriding config values,
+// e.g. CERA_SERVER_PORT overrides server.port
+const EnvPrefix = "CERA"
+
+// Config is the declarative configuration of a cera application,
+// it could be loaded from yaml, toml or json file with LoadConfig
+type Config struct {
+	Server  ServerConfig   `json:"server" yaml:"server" toml:"server"`
+	TLS     TLSConfig      `json:"tls" yaml:"tls" toml:"tls"`
+	Log     LogConfig      `json:"log" yaml:"log" toml:"log"`
+	Static  []StaticConfig `json:"static" yaml:"static" toml:"static"`
+	Auth    AuthConfig     `json:"auth" yaml:"auth" toml:"auth"`
+	Session SessionConfig  `json:"session" yaml:"session" toml:"session"`
+
+	// field path -> environment variable which overrode it
+	env map[string]string
+}
+
+type ServerConfig struct {
+	IP       string `json:"ip" yaml:"ip" toml:"ip"`
+	Port     string `json:"port" yaml:"port" toml:"port"`
+	Hostname string `json:"hostname" yaml:"hostname" toml:"hostname"`
+
+	// development, test or production, see http.Mode, empty keeps the behavior of servers without mode
+	Mode string `json:"mode" yaml:"mode" toml:"mode"`
+
+	// serve on unix socket instead of ip:port when set
+	UnixSocket     string `json:"unix_socket" yaml:"unix_socket" toml:"unix_socket"`
+	UnixSocketMode string `json:"unix_socket_mode" yaml:"unix_socket_mode" toml:"unix_socket_mode"` // octal, e.g. "0660"
+
+	// restart with listener handoff on SIGHUP
+	GracefulRestart bool `json:"graceful_restart" yaml:"graceful_restart" toml:"graceful_restart"`
+
+	ReadTimeout          Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout         Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout          Duration `json:"idle_timeout" yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxRequestBodySize   int      `json:"max_request_body_size" yaml:"max_request_body_size" toml:"max_request_body_size"`
+	Concurrency          int      `json:"concurrency" yaml:"concurrency" toml:"concurrency"`
+	MaxConnsPerIP        int      `json:"max_conns_per_ip" yaml:"max_conns_per_ip" toml:"max_conns_per_ip"`
+	MaxRequestsPerConn   int      `json:"max_requests_per_conn" yaml:"max_requests_per_conn" toml:"max_requests_per_conn"`
+	MaxHeaderSize        int      `json:"max_header_size" yaml:"max_header_size" toml:"max_header_size"`
+	DisableKeepalive     bool     `json:"disable_keepalive" yaml:"disable_keepalive" toml:"disable_keepalive"`
+	MaxKeepaliveDuration Duration `json:"max_keepalive_duration" yaml:"max_keepalive_duration" toml:"max_keepalive_duration"`
+}
+
+type TLSConfig struct {
+	Enable bool `json:"enable" yaml:"enable" toml:"enable"`
+	// self-signed certificate is generated when cert and key are empty,
+	// they are required in production mode
+	Cert string `json:"cert" yaml:"cert" toml:"cert"`
+	Key  string `json:"key" yaml:"key" toml:"key"`
+}
+
+type LogConfig struct {
+	Level        string `json:"level" yaml:"level" toml:"level"`    // trace, debug, info, warn, error
+	Format       string `json:"format" yaml:"format" toml:"format"` // text or json
+	ReportCaller bool   `json:"report_caller" yaml:"report_caller" toml:"report_caller"`
+	Access       bool   `json:"access" yaml:"access" toml:"access"` // log every request with access middleware
+}
+
+// StaticConfig serves files under Root at Path, Path must end with "/{filepath:*}"
+type StaticConfig struct {
+	Path string `json:"path" yaml:"path" toml:"path"`
+	Root string `json:"root" yaml:"root" toml:"root"`
+}
+
+type AuthConfig struct {
+	Enable      bool     `json:"enable" yaml:"enable" toml:"enable"`
+	Username    string   `json:"username" yaml:"username" toml:"username"`
+	Password    string   `json:"password" yaml:"password" toml:"password"`
+	LoginUrl    string   `json:"login_url" yaml:"login_url" toml:"login_url"`
+	SecurityKey string   `json:"security_key" yaml:"security_key" toml:"security_key"`
+	ExpireTime  Duration `json:"expire_time" yaml:"expire_time" toml:"expire_time"`
+	IgnoreUrls  []string `json:"ignore_urls" yaml:"ignore_urls" toml:"ignore_urls"`
+}
+
+type SessionConfig struct {
+	LifeCycle Duration `json:"life_cycle" yaml:"life_cycle" toml:"life_cycle"`
+}
+
+// Duration is a time.Duration written as string in config files, e.g. "30s", "5m"
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// ConfigError is returned when config file or environment has an invalid value
+type ConfigError struct {
+	File  string // config file or environment variable name
+	Line  int    // 0 if unknown
+	Field string // dotted field path, e.g. server.port
+	Msg   string
+}
+
+func (e *ConfigError) Error() string {
+	b := new(strings.Builder)
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(b, ":%d", e.Line)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(b, ": %s", e.Field)
+	}
+	fmt.Fprintf(b, ": %s", e.Msg)
+	return b.String()
+}
+
+// DefaultConfig returns the config used for values not set in config file
+func DefaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			IP:          "127.0.0.1",
+			Port:        "8080",
+			IdleTimeout: Duration{30 * time.Second},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Auth: AuthConfig{
+			LoginUrl:   "/crea_auth/login",
+			ExpireTime: Duration{time.Hour},
+		},
+		Session: SessionConfig{
+			LifeCycle: Duration{30 * time.Minute},
+		},
+	}
+}
+
+// LoadConfig reads config file by its extension (.yaml, .yml, .toml or .json), applies
+// environment variable overrides and validates the result.
+// The first invalid value is reported with its file and line.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := DefaultConfig()
+	var lines lineFinder
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = decodeYaml(data, c)
+		lines = yamlLines(data)
+	case ".toml":
+		err = decodeToml(data, c)
+		lines = tomlLines(data)
+	case ".json":
+		err = decodeJson(data, c)
+		lines = jsonLines(data)
+	default:
+		return nil, &ConfigError{File: path, Msg: fmt.Sprintf("unsupported config format %q", ext)}
+	}
+	if err != nil {
+		if ce, ok := err.(*ConfigError); ok {
+			ce.File = path
+			if ce.Line == 0 && ce.Field != "" {
+				ce.Line = lines[ce.Field]
+			}
+			return nil, ce
+		}
+		return nil, &ConfigError{File: path, Msg: err.Error()}
+	}
+	if err := c.LoadEnv(EnvPrefix); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		ce := err.(*ConfigError)
+		if name, ok := c.env[ce.Field]; ok {
+			ce.File = "env " + name
+		} else {
+			ce.File = path
+			ce.Line = lines[ce.Field]
+		}
+		return nil, ce
+	}
+	return c, nil
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+func decodeYaml(data []byte, c *Config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(c)
+	if err == nil || err.Error() == "EOF" {
+		return nil
+	}
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	if te, ok := err.(*yaml.TypeError); ok {
+		// report the first one only, as "line N: message"
+		msg = te.Errors[0]
+	}
+	ce := &ConfigError{}
+	if m := yamlLineRe.FindStringSubmatchIndex(msg); m != nil {
+		ce.Line, _ = strconv.Atoi(msg[m[2]:m[3]])
+		msg = strings.TrimLeft(msg[m[1]:], ": ")
+	}
+	ce.Msg = msg
+	return ce
+}
+
+var tomlPrefixRe = regexp.MustCompile(`^toml: line \d+( \(last key "[^"]*"\))?: `)
+
+func decodeToml(data []byte, c *Config) error {
+	md, err := toml.Decode(string(data), c)
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			// the position of the offending char, toml counts a newline it stops at to the next line
+			return &ConfigError{Line: offsetLine(data, int64(pe.Position.Start)),
+				Msg: tomlPrefixRe.ReplaceAllString(pe.Error(), "")}
+		}
+		return err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return &ConfigError{Field: undecoded[0].String(), Msg: "unknown field"}
+	}
+	return nil
+}
+
+func decodeJson(data []byte, c *Config) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(c)
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		return &ConfigError{Line: offsetLine(data, e.Offset), Msg: e.Error()}
+	case *json.UnmarshalTypeError:
+		return &ConfigError{Line: offsetLine(data, e.Offset), Field: e.Field,
+			Msg: fmt.Sprintf("cannot use %s as %s", e.Value, e.Type)}
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		// the decoder stops at the end of the object, the field is the last key with the name before
+		offset := dec.InputOffset()
+		if i := bytes.LastIndex(data[:offset], []byte(`"`+name+`"`)); i >= 0 {
+			offset = int64(i)
+		}
+		return &ConfigError{Line: offsetLine(data, offset), Field: name, Msg: "unknown field"}
+	}
+	return err
+}
+
+func offsetLine(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// LoadEnv overrides config values with environment variables named by prefix and field path,
+// e.g. CERA_SERVER_READ_TIMEOUT=10s, lists are comma separated
+func (c *Config) LoadEnv(prefix string) error {
+	if c.env == nil {
+		c.env = make(map[string]string)
+	}
+	return loadEnv(reflect.ValueOf(c).Elem(), prefix, "", c.env)
+}
+
+var textUnmarshaler = reflect.TypeOf((*interface{ UnmarshalText([]byte) error })(nil)).Elem()
+
+func loadEnv(v reflect.Value, env, field string, overrides map[string]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := env + "_" + strings.ToUpper(tag)
+		path := tag
+		if field != "" {
+			path = field + "." + tag
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !fv.Addr().Type().Implements(textUnmarshaler) {
+			if err := loadEnv(fv, name, path, overrides); err != nil {
+				return err
+			}
+			continue
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setString(fv, s); err != nil {
+			return &ConfigError{File: "env " + name, Field: path, Msg: err.Error()}
+		}
+		overrides[path] = name
+	}
+	return nil
+}
+
+// setString sets a field from its string representation
+func setString(v reflect.Value, s string) error {
+	if v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(interface{ UnmarshalText([]byte) error }).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can not be set from environment")
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("can not be set from environment")
+	}
+	return nil
+}
+
+// Validate checks config values, the error is a *ConfigError describing the first invalid value
+func (c *Config) Validate() error {
+	invalid := func(field, format string, args ...interface{}) error {
+		return &ConfigError{File: "config", Field: field, Msg: fmt.Sprintf(format, args...)}
+	}
+	s := c.Server
+	if s.UnixSocket == "" {
+		if s.IP != "" && net.ParseIP(s.IP) == nil {
+			return invalid("server.ip", "invalid ip address %q", s.IP)
+		}
+		if p, err := strconv.Atoi(s.Port); err != nil || p < 0 || p > 65535 {
+			return invalid("server.port", "invalid port %q", s.Port)
+		}
+	}
+	mode := http.Mode("")
+	if s.Mode != "" {
+		m, err := http.ParseMode(s.Mode)
+		if err != nil {
+			return invalid("server.mode", "unknown mode %q, development, test or production", s.Mode)
+		}
+		mode = m
+	}
+	if s.UnixSocketMode != "" {
+		if _, err := strconv.ParseUint(s.UnixSocketMode, 8, 32); err != nil {
+			return invalid("server.unix_socket_mode", "invalid octal file mode %q", s.UnixSocketMode)
+		}
+	}
+	durations := map[string]Duration{
+		"server.read_timeout":           s.ReadTimeout,
+		"server.write_timeout":          s.WriteTimeout,
+		"server.idle_timeout":           s.IdleTimeout,
+		"server.max_keepalive_duration": s.MaxKeepaliveDuration,
+		"auth.expire_time":              c.Auth.ExpireTime,
+		"session.life_cycle":            c.Session.LifeCycle,
+	}
+	for _, field := range sortedKeys(durations) {
+		if durations[field].Duration < 0 {
+			return invalid(field, "must not be negative")
+		}
+	}
+	limits := map[string]int{
+		"server.max_request_body_size": s.MaxRequestBodySize,
+		"server.concurrency":           s.Concurrency,
+		"server.max_conns_per_ip":      s.MaxConnsPerIP,
+		"server.max_requests_per_conn": s.MaxRequestsPerConn,
+		"server.max_header_size":       s.MaxHeaderSize,
+	}
+	for _, field := range sortedKeys(limits) {
+		if limits[field] < 0 {
+			return invalid(field, "must not be negative")
+		}
+	}
+	if lc := c.Session.LifeCycle.Duration; lc < time.Minute || lc%time.Minute != 0 {
+		return invalid("session.life_cycle", "%s must be whole minutes, at least 1m", lc)
+	}
+	opts := http.DefaultOptions()
+	for _, opt := range c.ServerOptions() {
+		opt(&opts)
+	}
+	if err := opts.Validate(); err != nil {
+		oe := err.(*http.OptionError)
+		field, ok := optionFields[oe.Option]
+		if !ok {
+			field = "server"
+		}
+		return invalid(field, "%s", oe.Msg)
+	}
+	if c.TLS.Enable && (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		return invalid("tls", "cert and key must be both set or both empty")
+	}
+	if c.TLS.Enable && c.TLS.Cert == "" && mode == http.ModeProduction {
+		return invalid("tls.enable", "cert and key are required in production mode, self-signed certs are not generated")
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "trace", "debug", "info", "warn", "warning", "error":
+	default:
+		return invalid("log.level", "unknown log level %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		return invalid("log.format", "unknown log format %q, text or json", c.Log.Format)
+	}
+	for i, st := range c.Static {
+		if !strings.HasSuffix(st.Path, "/{filepath:*}") || !strings.HasPrefix(st.Path, "/") {
+			return invalid(fmt.Sprintf("static.%d.path", i), "path %q must begin with '/' and end with /{filepath:*}", st.Path)
+		}
+		if st.Root == "" {
+			return invalid(fmt.Sprintf("static.%d.root", i), "root must not be empty")
+		}
+	}
+	if c.Auth.Enable {
+		if c.Auth.SecurityKey == "" {
+			return invalid("auth.security_key", "security key must be set when auth is enabled")
+		}
+		if c.Auth.Username == "" || c.Auth.Password == "" {
+			return invalid("auth", "username and password must be set when auth is enabled")
+		}
+	}
+	return nil
+}
+
+// optionFields are the config fields of the server options
+var optionFields = map[string]string{
+	"ReadTimeout":          "server.read_timeout",
+	"WriteTimeout":         "server.write_timeout",
+	"IdleTimeout":          "server.idle_timeout",
+	"MaxKeepaliveDuration": "server.max_keepalive_duration",
+	"MaxRequestBodySize":   "server.max_request_body_size",
+	"Concurrency":          "server.concurrency",
+	"MaxConnsPerIP":        "server.max_conns_per_ip",
+	"MaxRequestsPerConn":   "server.max_requests_per_conn",
+	"ReadBufferSize":       "server.max_header_size",
+}
+
+// sortedKeys returns the keys of a string keyed map in order, so the reported error is stable
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.String()
+	}
+	sort.Strings(out)
+	return out
+}
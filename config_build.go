@@ -0,0 +1,117 @@
+package cera
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xxxmailk/cera/http"
+	"github.com/xxxmailk/cera/log"
+	"github.com/xxxmailk/cera/middlewares/access"
+	"github.com/xxxmailk/cera/middlewares/auth"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+// NewLogger creates logger by log config
+func (c *Config) NewLogger() log.SimpleLogger {
+	l := logrus.New()
+	if lvl, err := logrus.ParseLevel(strings.ToLower(c.Log.Level)); err == nil {
+		l.SetLevel(lvl)
+	}
+	if c.Log.Format == "json" {
+		l.Formatter = &logrus.JSONFormatter{}
+	} else {
+		l.Formatter = &logrus.TextFormatter{}
+	}
+	l.ReportCaller = c.Log.ReportCaller
+	return l
+}
+
+// NewAuth creates jwt auth middleware by auth config, returns nil if auth is not enabled
+func (c *Config) NewAuth(logger log.SimpleLogger) *auth.CeraAuth {
+	if !c.Auth.Enable {
+		return nil
+	}
+	a := c.Auth
+	return auth.NewCeraAuth(a.Username, a.Password, a.LoginUrl, a.SecurityKey,
+		int64(a.ExpireTime.Seconds()), nil, logger, a.IgnoreUrls)
+}
+
+// NewSessionStore creates the store of sessions by session config
+func (c *Config) NewSessionStore() *view.SessionStore {
+	return view.NewSessionStore(c.Session.LifeCycle.Duration)
+}
+
+// ServerOptions returns the server limits of server config
+func (c *Config) ServerOptions() []http.Option {
+	s := c.Server
+	opts := []http.Option{
+		http.WithReadTimeout(s.ReadTimeout.Duration),
+		http.WithWriteTimeout(s.WriteTimeout.Duration),
+		http.WithIdleTimeout(s.IdleTimeout.Duration),
+		http.WithMaxConnsPerIP(s.MaxConnsPerIP),
+		http.WithMaxRequestsPerConn(s.MaxRequestsPerConn),
+		http.WithKeepalive(!s.DisableKeepalive, s.MaxKeepaliveDuration.Duration),
+	}
+	// zero keeps the default
+	if s.MaxRequestBodySize > 0 {
+		opts = append(opts, http.WithMaxRequestBodySize(s.MaxRequestBodySize))
+	}
+	if s.Concurrency > 0 {
+		opts = append(opts, http.WithConcurrency(s.Concurrency))
+	}
+	if s.MaxHeaderSize > 0 {
+		opts = append(opts, http.WithMaxHeaderSize(s.MaxHeaderSize))
+	}
+	return opts
+}
+
+// NewServer builds the http server with logger, middlewares, static files and the session
+// store of the router from config. Static routes are added to the router r.
+// Start the server with Start(), or StartTls() if tls is enabled, see Serve().
+func (c *Config) NewServer(r *router.Router) *http.Serve {
+	logger := c.NewLogger()
+	s := http.NewTLSServe(c.Server.IP, c.Server.Port, c.ServerOptions()...).(*http.Serve)
+	s.SetLogger(logger)
+	if c.Server.Mode != "" {
+		if mode, err := http.ParseMode(c.Server.Mode); err == nil {
+			s.SetMode(mode)
+		}
+	}
+	if c.Server.Hostname != "" {
+		s.SetHostname(c.Server.Hostname)
+	}
+	if c.Server.UnixSocket != "" {
+		mode, _ := strconv.ParseUint(c.Server.UnixSocketMode, 8, 32)
+		s.SetUnixSocket(c.Server.UnixSocket, os.FileMode(mode))
+	}
+	if c.Server.GracefulRestart {
+		s.EnableGracefulRestart()
+	}
+	if c.TLS.Cert != "" {
+		s.SetSslKeyCert(c.TLS.Key, c.TLS.Cert)
+	}
+	if a := c.NewAuth(logger); a != nil {
+		s.UseMiddleWare(a)
+	}
+	if c.Log.Access {
+		s.AtLast(access.NewAccessMiddleware(logger))
+	}
+	for _, st := range c.Static {
+		r.ServeFiles(st.Path, st.Root)
+	}
+	r.Sessions = c.NewSessionStore()
+	s.SetRouter(r)
+	return s
+}
+
+// Serve builds the server from config and starts it, it blocks until the server stopped
+func (c *Config) Serve(r *router.Router) error {
+	s := c.NewServer(r)
+	if c.TLS.Enable {
+		return s.StartTls()
+	}
+	return s.Start()
+}
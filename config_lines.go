@@ -0,0 +1,135 @@
+package cera
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lineFinder maps dotted field paths (e.g. server.port, static.0.path) to their line in config file
+type lineFinder map[string]int
+
+func yamlLines(data []byte) lineFinder {
+	lines := make(lineFinder)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return lines
+	}
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				p := joinField(path, n.Content[i].Value)
+				lines[p] = n.Content[i].Line
+				walk(n.Content[i+1], p)
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				p := joinField(path, strconv.Itoa(i))
+				lines[p] = item.Line
+				walk(item, p)
+			}
+		}
+	}
+	walk(doc.Content[0], "")
+	return lines
+}
+
+func jsonLines(data []byte) lineFinder {
+	lines := make(lineFinder)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// line of the next token, InputOffset points to the end of the last token
+	nextLine := func() int {
+		off := dec.InputOffset()
+		for off < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[off]) >= 0 {
+			off++
+		}
+		return offsetLine(data, off)
+	}
+	var value func(path string) bool
+	value = func(path string) bool {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				line := nextLine()
+				tok, err := dec.Token()
+				if err != nil {
+					return false
+				}
+				key := joinField(path, tok.(string))
+				lines[key] = line
+				if !value(key) {
+					return false
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				p := joinField(path, strconv.Itoa(i))
+				lines[p] = nextLine()
+				if !value(p) {
+					return false
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err == nil
+	}
+	value("")
+	return lines
+}
+
+// tomlLines finds keys by scanning lines, it understands tables, arrays of tables
+// and dotted keys which covers the config layout
+func tomlLines(data []byte) lineFinder {
+	lines := make(lineFinder)
+	arrays := make(map[string]int)
+	table := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		l := strings.TrimSpace(sc.Text())
+		switch {
+		case l == "" || l[0] == '#':
+		case strings.HasPrefix(l, "[["):
+			// headers without closing brackets are syntax errors reported by the decoder
+			end := strings.Index(l, "]]")
+			if end < 0 {
+				continue
+			}
+			name := strings.TrimSpace(strings.Trim(l[:end], "[ "))
+			table = joinField(name, strconv.Itoa(arrays[name]))
+			arrays[name]++
+			lines[name] = n
+			lines[table] = n
+		case l[0] == '[':
+			end := strings.Index(l, "]")
+			if end < 0 {
+				continue
+			}
+			table = strings.TrimSpace(l[1:end])
+			lines[table] = n
+		default:
+			if i := strings.IndexByte(l, '='); i > 0 {
+				key := strings.Trim(strings.TrimSpace(l[:i]), `"'`)
+				lines[joinField(table, key)] = n
+			}
+		}
+	}
+	return lines
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
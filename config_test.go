@@ -0,0 +1,154 @@
+package cera
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeConfig writes the config file with the name to a temporary directory
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cera-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setEnv sets the environment variables until the test ends
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+		k := k
+		t.Cleanup(func() { os.Unsetenv(k) })
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		line  int
+		field string
+	}{
+		{"yaml syntax", "c.yaml", "server:\n  port: 80\n  ip: [\n", 3, ""},
+		{"yaml unknown field", "c.yaml", "server:\n  port: 80\n  color: red\n", 3, ""},
+		{"yaml type", "c.yaml", "server:\n  port: 80\n  concurrency: many\n", 3, ""},
+		{"yaml invalid value", "c.yaml", "server:\n  port: 80\nlog:\n  level: loud\n", 4, "log.level"},
+		{"toml syntax", "c.toml", "[server]\nport = \"80\"\nip = \n", 3, ""},
+		{"toml truncated table", "c.toml", "[server\nport = \"80\"\n", 1, ""},
+		{"toml truncated array of tables", "c.toml", "[server]\nport = \"80\"\n[[static\n", 3, ""},
+		{"toml unknown field", "c.toml", "[server]\nport = \"80\"\ncolor = \"red\"\n", 3, "server.color"},
+		{"toml invalid value", "c.toml", "[server]\nport = \"80\"\n\n[log]\nlevel = \"loud\"\n", 5, "log.level"},
+		{"json syntax", "c.json", "{\n  \"server\": {\n    \"port\": \"80\",\n  }\n}\n", 4, ""},
+		{"json unknown field", "c.json", "{\n  \"server\": {\n    \"color\": \"red\"\n  }\n}\n", 3, "color"},
+		{"json type", "c.json", "{\n  \"server\": {\n    \"concurrency\": \"many\"\n  }\n}\n", 3, "server.concurrency"},
+		{"json invalid value", "c.json", "{\n  \"log\": {\n    \"level\": \"loud\"\n  }\n}\n", 3, "log.level"},
+		{"session life cycle", "c.yaml", "session:\n  life_cycle: 30s\n", 2, "session.life_cycle"},
+		{"server option", "c.yaml", "server:\n  max_header_size: 100\n", 2, "server.max_header_size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file, tt.data)
+
+			_, err := LoadConfig(path)
+			ce, ok := err.(*ConfigError)
+			if !ok {
+				t.Fatalf("error was %v; want a *ConfigError", err)
+			}
+			if ce.File != path {
+				t.Errorf("file was %q; want %q", ce.File, path)
+			}
+			if ce.Line != tt.line {
+				t.Errorf("line was %d; want %d (%s)", ce.Line, tt.line, ce)
+			}
+			if ce.Field != tt.field {
+				t.Errorf("field was %q; want %q (%s)", ce.Field, tt.field, ce)
+			}
+			if ce.Msg == "" {
+				t.Errorf("error has no message (%s)", ce)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	path := writeConfig(t, "c.yaml", "server:\n  port: 80\n  read_timeout: 5s\nauth:\n  ignore_urls: [/health]\n")
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(c *Config) bool
+		err   string // the File of the expected ConfigError, empty if loading succeeds
+	}{
+		{
+			name:  "file values",
+			check: func(c *Config) bool { return c.Server.Port == "80" && c.Server.ReadTimeout.Duration == 5*time.Second },
+		},
+		{
+			name: "string and duration",
+			env:  map[string]string{"CERA_SERVER_PORT": "9090", "CERA_SERVER_READ_TIMEOUT": "10s"},
+			check: func(c *Config) bool {
+				return c.Server.Port == "9090" && c.Server.ReadTimeout.Duration == 10*time.Second
+			},
+		},
+		{
+			name: "list",
+			env:  map[string]string{"CERA_AUTH_IGNORE_URLS": "/health,/metrics"},
+			check: func(c *Config) bool {
+				return reflect.DeepEqual(c.Auth.IgnoreUrls, []string{"/health", "/metrics"})
+			},
+		},
+		{
+			name:  "bool and int",
+			env:   map[string]string{"CERA_SERVER_DISABLE_KEEPALIVE": "true", "CERA_SERVER_CONCURRENCY": "64"},
+			check: func(c *Config) bool { return c.Server.DisableKeepalive && c.Server.Concurrency == 64 },
+		},
+		{
+			name: "unparsable value",
+			env:  map[string]string{"CERA_SERVER_READ_TIMEOUT": "soon"},
+			err:  "env CERA_SERVER_READ_TIMEOUT",
+		},
+		{
+			name: "invalid value",
+			env:  map[string]string{"CERA_LOG_LEVEL": "loud"},
+			err:  "env CERA_LOG_LEVEL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+
+			c, err := LoadConfig(path)
+			if tt.err != "" {
+				ce, ok := err.(*ConfigError)
+				if !ok || ce.File != tt.err {
+					t.Fatalf("error was %v; want a *ConfigError of %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(c) {
+				t.Errorf("config was %+v", c)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/xxxmailk/cera
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/google/uuid v1.1.1
 	github.com/savsgio/gotils v0.0.0-20200616100644-13ff1fd2c28c
@@ -11,6 +12,7 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0
 	github.com/valyala/fasthttp v1.34.0
 	golang.org/x/net v0.0.0-20220225172249-27dd8689420f
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 replace github.com/xxxmailk/cera => ./
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+// number of source lines shown around the line of a stack frame
+const snippetLines = 5
+
+// SetDebug enables the debug error page, panics are then answered with a page showing
+// the panic value, the stack with source snippets, the request headers and the matched
+// route. It must not be enabled in production, panics are answered with a generic
+// 500 Internal Server Error otherwise. Panics are logged with their stack in both modes.
+func (s *Serve) SetDebug(debug bool) {
+	s.debug = debug
+}
+
+// recover recovers panics of middlewares, panics of views are recovered by the router
+func (s *Serve) recover(ctx *fasthttp.RequestCtx) {
+	if rcv := recover(); rcv != nil {
+		s.panicHandler(ctx, rcv)
+	}
+}
+
+// panicHandler is the PanicHandler of the router unless it has one, it must be
+// called by the deferred function recovering the panic
+func (s *Serve) panicHandler(ctx *fasthttp.RequestCtx, rcv interface{}) {
+	stack := debug.Stack()
+	s.logger.Errorf("panic serving %s %s: %v\n%s", ctx.Method(), ctx.RequestURI(), rcv, stack)
+
+	info, matched := ctx.UserValue(router.MatchedRouteParam).(router.RouteInfo)
+	if !s.debug {
+		var v view.MethodViewer
+		if matched {
+			v = info.Handler
+		}
+		s.router.RenderError(ctx, view.NewHTTPError(fasthttp.StatusInternalServerError, ""), v)
+		return
+	}
+
+	page := debugPage{
+		Value:  fmt.Sprint(rcv),
+		Type:   fmt.Sprintf("%T", rcv),
+		Method: string(ctx.Method()),
+		URI:    string(ctx.RequestURI()),
+		Frames: panicFrames(),
+		Stack:  string(stack),
+	}
+	if matched {
+		page.Route = &info
+	}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		page.Headers = append(page.Headers, [2]string{string(key), string(value)})
+	})
+	sort.Slice(page.Headers, func(i, j int) bool {
+		return page.Headers[i][0] < page.Headers[j][0]
+	})
+
+	buf := bytes.Buffer{}
+	if err := debugPageTemplate.Execute(&buf, page); err != nil {
+		s.logger.Errorf("render debug page failed, %s", err)
+		s.router.RenderError(ctx, view.NewHTTPError(fasthttp.StatusInternalServerError, ""), nil)
+		return
+	}
+
+	ctx.Response.ResetBody()
+	ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBody(buf.Bytes())
+}
+
+type debugPage struct {
+	Value   string
+	Type    string
+	Method  string
+	URI     string
+	Route   *router.RouteInfo
+	Headers [][2]string
+	Frames  []stackFrame
+	Stack   string
+}
+
+type stackFrame struct {
+	Function string
+	File     string
+	Line     int
+	Source   []sourceLine
+}
+
+type sourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+// panicFrames returns the frames of the panicking goroutine from the panic on,
+// the frames of the recovery and of the runtime are skipped
+func panicFrames() []stackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var result []stackFrame
+	panicked := false
+	sources := make(map[string][]string)
+	for {
+		f, more := frames.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			panicked = true
+		case panicked && !strings.HasPrefix(f.Function, "runtime."):
+			result = append(result, stackFrame{
+				Function: f.Function,
+				File:     f.File,
+				Line:     f.Line,
+				Source:   snippet(sources, f.File, f.Line),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+
+	return result
+}
+
+// snippet returns the source lines around line of the file, files are read once
+func snippet(sources map[string][]string, file string, line int) []sourceLine {
+	lines, ok := sources[file]
+	if !ok {
+		if data, err := ioutil.ReadFile(file); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		sources[file] = lines
+	}
+
+	var result []sourceLine
+	for i := line - snippetLines; i <= line+snippetLines; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+		result = append(result, sourceLine{Number: i, Text: lines[i-1], Current: i == line})
+	}
+
+	return result
+}
+
+var debugPageTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>panic: {{.Value}}</title>
+<style>
+body{font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;margin:0;color:#333}
+header{background:#b71c1c;color:#fff;padding:16px 32px}
+header h1{margin:0;font-size:22px;word-break:break-all}
+header p{margin:4px 0 0;color:#ffcdd2}
+main{padding:8px 32px}
+h2{font-size:17px;border-bottom:1px solid #ddd;padding-bottom:4px}
+table{border-collapse:collapse;font-size:13px}
+td{padding:3px 12px 3px 0;vertical-align:top;font-family:monospace;word-break:break-all}
+.frame{margin:12px 0}
+.func{font-family:monospace;font-weight:bold}
+.file{font-family:monospace;color:#777;font-size:12px}
+pre{background:#f6f6f6;margin:4px 0;padding:6px 0;font-size:12px;overflow:auto}
+pre span{display:block;padding:0 8px}
+pre .cur{background:#ffcdd2}
+pre b{display:inline-block;width:48px;color:#999;font-weight:normal}
+</style>
+</head>
+<body>
+<header>
+<h1>panic: {{.Value}}</h1>
+<p>{{.Type}} serving {{.Method}} {{.URI}}</p>
+</header>
+<main>
+<h2>Route</h2>
+{{with .Route}}<table>
+<tr><td>method</td><td>{{.Method}}</td></tr>
+{{if .Host}}<tr><td>host</td><td>{{.Host}}</td></tr>{{end}}
+<tr><td>path</td><td>{{.Path}}</td></tr>
+{{if .Name}}<tr><td>name</td><td>{{.Name}}</td></tr>{{end}}
+<tr><td>view</td><td>{{.View}}</td></tr>
+{{if .Version}}<tr><td>version</td><td>{{.Version}}</td></tr>{{end}}
+</table>{{else}}<p>no route matched the request</p>{{end}}
+<h2>Stack</h2>
+{{range .Frames}}<div class="frame">
+<div class="func">{{.Function}}</div>
+<div class="file">{{.File}}:{{.Line}}</div>
+{{if .Source}}<pre>{{range .Source}}<span{{if .Current}} class="cur"{{end}}><b>{{.Number}}</b>{{.Text}}</span>{{end}}</pre>{{end}}
+</div>
+{{end}}
+<h2>Request headers</h2>
+<table>
+{{range .Headers}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+<h2>Goroutine</h2>
+<pre>{{.Stack}}</pre>
+</main>
+</body>
+</html>
+`))
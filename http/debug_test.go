@@ -0,0 +1,50 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+)
+
+func TestDebugPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *Serve)
+		page  bool
+	}{
+		{"no mode", func(s *Serve) {}, false},
+		{"debug", func(s *Serve) { s.SetDebug(true) }, true},
+		{"development", func(s *Serve) { s.SetMode(ModeDevelopment) }, true},
+		{"development without debug", func(s *Serve) { s.SetMode(ModeDevelopment); s.SetDebug(false) }, false},
+		{"test", func(s *Serve) { s.SetMode(ModeTest) }, false},
+		{"production", func(s *Serve) { s.SetMode(ModeProduction) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router.New()
+			r.HandleFunc(fasthttp.MethodGet, "/boom", func(ctx *fasthttp.RequestCtx) {
+				panic("secret value")
+			})
+			s := newTestServe()
+			tt.setup(s)
+
+			ctx := serve(t, s, r, "/boom")
+			if status := ctx.Response.StatusCode(); status != fasthttp.StatusInternalServerError {
+				t.Errorf("status was %d; want %d", status, fasthttp.StatusInternalServerError)
+			}
+			body := string(ctx.Response.Body())
+			if shown := strings.Contains(body, "panic: secret value"); shown != tt.page {
+				t.Errorf("debug page shown was %t; want %t, body %q", shown, tt.page, body)
+			}
+			if tt.page && !strings.Contains(body, "/boom") {
+				t.Error("debug page doesn't show the matched route")
+			}
+			if !tt.page && strings.Contains(body, "secret value") {
+				t.Errorf("panic value leaked in %q", body)
+			}
+		})
+	}
+}
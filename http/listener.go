@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// first file descriptor passed by systemd socket activation
+const listenFdsStart = 3
+
+var (
+	ErrNoSystemdListener = errors.New("no listener has been passed by systemd socket activation")
+	ErrSocketInUse       = errors.New("unix socket is in use by another process")
+)
+
+// SetListener serve on a listener opened by the caller,
+// the listener will be closed when server stopped
+func (s *Serve) SetListener(ln net.Listener) {
+	s.listener = ln
+}
+
+// SetUnixSocket serve on an unix domain socket instead of tcp,
+// mode is the file permission of the socket file, 0 means keep the umask default
+func (s *Serve) SetUnixSocket(path string, mode os.FileMode) {
+	s.unixSocket = path
+	s.unixSocketMode = mode
+}
+
+// SetUnixSocketOwner change the owner of the unix socket file after it has been created,
+// -1 means keep the uid or gid unchanged
+func (s *Serve) SetUnixSocketOwner(uid, gid int) {
+	s.unixSocketUid, s.unixSocketGid = uid, gid
+	s.unixSocketChown = true
+}
+
+// UseSystemdSocket serve on a listener passed by systemd socket activation.
+// name is matched against LISTEN_FDNAMES (FileDescriptorName= in the .socket unit),
+// empty name uses the first passed listener
+func (s *Serve) UseSystemdSocket(name string) {
+	s.systemd = true
+	s.systemdName = name
+}
+
+// listen returns the listener the server should serve on and tells the parent
+// process we are ready if this process is started by Restart()
+func (s *Serve) listen() (net.Listener, error) {
+	ln, err := s.openListener()
+	if err != nil {
+		return nil, err
+	}
+	s.ln = ln
+	s.watchRestartSignal()
+	notifyReady()
+	return ln, nil
+}
+
+// openListener opens listener by priority:
+// listener handed over by Restart() > caller listener > systemd listener > unix socket > tcp
+func (s *Serve) openListener() (net.Listener, error) {
+	if ln, ok, err := inheritedListener(); ok {
+		return ln, err
+	}
+	switch {
+	case s.listener != nil:
+		return s.listener, nil
+	case s.systemd:
+		return systemdListener(s.systemdName)
+	case s.unixSocket != "":
+		return s.listenUnix()
+	}
+	return net.Listen("tcp", net.JoinHostPort(s.ip, s.port))
+}
+
+func (s *Serve) listenUnix() (net.Listener, error) {
+	if err := removeStaleSocket(s.unixSocket); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", s.unixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if s.unixSocketMode != 0 {
+		if err := os.Chmod(s.unixSocket, s.unixSocketMode); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	if s.unixSocketChown {
+		if err := os.Chown(s.unixSocket, s.unixSocketUid, s.unixSocketGid); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the socket file left by a crashed process,
+// a socket which still accepts connections will not be removed
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s already exists and is not a unix socket", path)
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return ErrSocketInUse
+	}
+	return os.Remove(path)
+}
+
+// closeListeners closes all listeners except the one at index keep
+func closeListeners(listeners []net.Listener, keep int) {
+	for i, ln := range listeners {
+		if i != keep {
+			ln.Close()
+		}
+	}
+}
+
+// fileListener creates a listener from a file descriptor, the descriptor is duplicated
+// by net.FileListener so the original file is closed
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+	return net.FileListener(f)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package http
+
+import "net"
+
+// SystemdListeners returns ErrNoSystemdListener, systemd socket activation is only
+// available on unix systems
+func SystemdListeners() ([]net.Listener, []string, error) {
+	return nil, nil, ErrNoSystemdListener
+}
+
+func systemdListener(name string) (net.Listener, error) {
+	return nil, ErrNoSystemdListener
+}
@@ -0,0 +1,116 @@
+//go:build unix
+
+package http
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := tempDir(t)
+
+	inUse := filepath.Join(dir, "in-use.sock")
+	ln, err := net.Listen("unix", inUse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stale := filepath.Join(dir, "stale.sock")
+	staleLn, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staleLn.(*net.UnixListener).SetUnlinkOnClose(false)
+	staleLn.Close()
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+		err  error // expected error, nil if any error is expected
+	}{
+		{"new socket", filepath.Join(dir, "new.sock"), true, nil},
+		{"stale socket", stale, true, nil},
+		{"socket in use", inUse, false, ErrSocketInUse},
+		{"regular file", file, false, nil},
+		{"missing directory", filepath.Join(dir, "missing", "s.sock"), false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServe()
+			s.SetUnixSocket(tt.path, 0660)
+
+			ln, err := s.openListener()
+			if !tt.ok {
+				if err == nil {
+					ln.Close()
+					t.Fatalf("listening on %s succeeded; want an error", tt.path)
+				}
+				if tt.err != nil && err != tt.err {
+					t.Errorf("error was %v; want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+
+			fi, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := fi.Mode().Perm(); mode != 0660 {
+				t.Errorf("mode was %o; want %o", mode, 0660)
+			}
+		})
+	}
+}
+
+func TestSystemdListeners(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"not activated", nil},
+		{"pid mismatch", map[string]string{"LISTEN_PID": strconv.Itoa(os.Getpid() + 1), "LISTEN_FDS": "1"}},
+		{"invalid pid", map[string]string{"LISTEN_PID": "self", "LISTEN_FDS": "1"}},
+		{"no fds", map[string]string{"LISTEN_PID": pid, "LISTEN_FDS": "0"}},
+		{"invalid fds", map[string]string{"LISTEN_PID": pid, "LISTEN_FDS": "many"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+
+			if _, _, err := SystemdListeners(); err != ErrNoSystemdListener {
+				t.Errorf("error was %v; want %v", err, ErrNoSystemdListener)
+			}
+			for _, k := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+				if v, ok := os.LookupEnv(k); ok {
+					t.Errorf("%s=%s is still set", k, v)
+				}
+			}
+		})
+	}
+
+	s := newTestServe()
+	s.UseSystemdSocket("web")
+	if _, err := s.openListener(); err != ErrNoSystemdListener {
+		t.Errorf("error of a server without activation was %v; want %v", err, ErrNoSystemdListener)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package http
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SystemdListeners returns the listeners passed by systemd socket activation in fd order,
+// together with their LISTEN_FDNAMES names. Listeners without name are named by their fd number.
+// The LISTEN_* environment variables are unset so child processes won't inherit them.
+func SystemdListeners() ([]net.Listener, []string, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, ErrNoSystemdListener
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil, ErrNoSystemdListener
+	}
+	var fdNames []string
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		fdNames = strings.Split(v, ":")
+	}
+
+	listeners := make([]net.Listener, 0, n)
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+		name := strconv.Itoa(fd)
+		if i < len(fdNames) && fdNames[i] != "" {
+			name = fdNames[i]
+		}
+		ln, err := fileListener(uintptr(fd), name)
+		if err != nil {
+			closeListeners(listeners, -1)
+			return nil, nil, err
+		}
+		listeners = append(listeners, ln)
+		names = append(names, name)
+	}
+	return listeners, names, nil
+}
+
+// systemdListener picks the listener with the given name, empty name picks the first one.
+// The listeners not picked are closed.
+func systemdListener(name string) (net.Listener, error) {
+	listeners, names, err := SystemdListeners()
+	if err != nil {
+		return nil, err
+	}
+	for i := range listeners {
+		if name == "" || names[i] == name {
+			closeListeners(listeners, i)
+			return listeners[i], nil
+		}
+	}
+	closeListeners(listeners, -1)
+	return nil, fmt.Errorf("systemd listener %q not found", name)
+}
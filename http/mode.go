@@ -0,0 +1,98 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// Mode is the environment the server runs in, it switches framework behavior:
+//                              development  test  production
+//     template hot reload      yes          yes   no
+//     debug error page         yes          no    no
+//     routing decisions log    yes          no    no
+//     self-signed tls          yes          yes   no
+//     strict security headers  no           no    yes
+// Servers without a mode keep the behavior of servers before modes: templates are
+// reloaded and self-signed tls certs are generated, the other switches are off. Production
+// mode is opt-in. The switches are applied when the server starts, the debug error page
+// could be changed by SetDebug after setting the mode.
+type Mode string
+
+const (
+	ModeDevelopment Mode = "development"
+	ModeTest        Mode = "test"
+	ModeProduction  Mode = "production"
+)
+
+// ParseMode parses the mode name, "dev" and "prod" are accepted as well
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "development", "dev":
+		return ModeDevelopment, nil
+	case "test":
+		return ModeTest, nil
+	case "production", "prod":
+		return ModeProduction, nil
+	}
+	return "", fmt.Errorf("unknown mode %q, development, test or production", s)
+}
+
+// securityHeaders are set to responses in production mode, views could override them
+var securityHeaders = [][2]string{
+	{"X-Content-Type-Options", "nosniff"},
+	{"X-Frame-Options", "DENY"},
+	{"Referrer-Policy", "strict-origin-when-cross-origin"},
+	{"Cross-Origin-Opener-Policy", "same-origin"},
+}
+
+// SetMode sets the mode of the server and the switches of the mode,
+// it should be called before Start()
+func (s *Serve) SetMode(m Mode) {
+	s.mode = m
+	s.debug = m == ModeDevelopment
+	s.logRouting = m == ModeDevelopment
+	s.selfSigned = m != ModeProduction
+	s.strictHeaders = m == ModeProduction
+	s.templateReload = m != ModeProduction
+}
+
+// unsetMode sets the switches of servers without a mode
+func (s *Serve) unsetMode() {
+	s.mode = ""
+	s.debug = false
+	s.logRouting = false
+	s.selfSigned = true
+	s.strictHeaders = false
+	s.templateReload = true
+}
+
+// Mode returns the mode of the server, empty if no mode is set
+func (s *Serve) Mode() Mode {
+	return s.mode
+}
+
+// applyMode applies the switches of the mode to the router and the views
+func (s *Serve) applyMode() {
+	if !s.templateReload {
+		if s.router.Views == nil {
+			s.router.Views = &view.Options{}
+		}
+		s.router.Views.TemplateCache = true
+	}
+	if s.logRouting {
+		s.router.LogRouting = true
+	}
+}
+
+// setSecurityHeaders sets the strict security headers, HSTS only to responses of tls servers
+func (s *Serve) setSecurityHeaders(ctx *fasthttp.RequestCtx) {
+	for _, h := range securityHeaders {
+		ctx.Response.Header.Set(h[0], h[1])
+	}
+	if s.tls {
+		ctx.Response.Header.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+	}
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		s    string
+		mode Mode
+		ok   bool
+	}{
+		{"development", ModeDevelopment, true},
+		{"dev", ModeDevelopment, true},
+		{"Test", ModeTest, true},
+		{"PROD", ModeProduction, true},
+		{"staging", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			mode, err := ParseMode(tt.s)
+			if (err == nil) != tt.ok {
+				t.Errorf("error was %v; want ok %t", err, tt.ok)
+			}
+			if mode != tt.mode {
+				t.Errorf("mode was %q; want %q", mode, tt.mode)
+			}
+		})
+	}
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		mode           Mode
+		logRouting     bool
+		selfSigned     bool
+		strictHeaders  bool
+		templateReload bool
+	}{
+		{"", false, true, false, true},
+		{ModeDevelopment, true, true, false, true},
+		{ModeTest, false, true, false, true},
+		{ModeProduction, false, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			r := router.New()
+			r.HandleFunc(fasthttp.MethodGet, "/", func(ctx *fasthttp.RequestCtx) {})
+			s := newTestServe()
+			if tt.mode != "" {
+				s.SetMode(tt.mode)
+			}
+
+			ctx := serve(t, s, r, "/")
+			if s.Mode() != tt.mode {
+				t.Errorf("mode was %q; want %q", s.Mode(), tt.mode)
+			}
+			if r.LogRouting != tt.logRouting {
+				t.Errorf("routing log was %t; want %t", r.LogRouting, tt.logRouting)
+			}
+			if s.selfSigned != tt.selfSigned {
+				t.Errorf("self-signed was %t; want %t", s.selfSigned, tt.selfSigned)
+			}
+			if cache := r.Views != nil && r.Views.TemplateCache; cache == tt.templateReload {
+				t.Errorf("template cache was %t; want %t", cache, !tt.templateReload)
+			}
+			strict := len(ctx.Response.Header.Peek("X-Content-Type-Options")) > 0
+			if strict != tt.strictHeaders {
+				t.Errorf("security headers were set %t; want %t", strict, tt.strictHeaders)
+			}
+			if hsts := ctx.Response.Header.Peek("Strict-Transport-Security"); len(hsts) > 0 {
+				t.Errorf("hsts %q was set by a server without tls", hsts)
+			}
+		})
+	}
+}
+
+func TestProductionRequiresCerts(t *testing.T) {
+	s := NewTLSServe("127.0.0.1", "0").(*Serve)
+	s.SetLogger(newTestServe().logger)
+	s.SetRouter(router.New())
+	s.SetMode(ModeProduction)
+
+	if err := s.StartTls(); err == nil {
+		t.Error("tls server without certs started in production mode")
+	}
+}
@@ -0,0 +1,216 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Options are the limits and connection settings of the underlying fasthttp.Server.
+// Zero value of a limit means no limit unless the default says otherwise.
+type Options struct {
+	// server name sent in response headers, default "" (fasthttp default name)
+	Name string
+
+	// maximum duration for reading the full request including body, default 0 (no timeout)
+	ReadTimeout time.Duration
+
+	// maximum duration before timing out writes of the response, default 0 (no timeout)
+	WriteTimeout time.Duration
+
+	// maximum time to wait for the next request when keep-alive is enabled, default 30s
+	IdleTimeout time.Duration
+
+	// maximum request body size in bytes, default 4MB
+	MaxRequestBodySize int
+
+	// maximum number of concurrent connections the server may serve, default 256 * 1024
+	Concurrency int
+
+	// maximum number of concurrent client connections allowed per IP, default 0 (unlimited)
+	MaxConnsPerIP int
+
+	// maximum number of requests served per connection, default 0 (unlimited)
+	MaxRequestsPerConn int
+
+	// per-connection buffer size for request reading, it also limits the maximum header size,
+	// default 4096
+	ReadBufferSize int
+
+	// per-connection buffer size for response writing, default 4096
+	WriteBufferSize int
+
+	// close connection after the response has been sent, default false
+	DisableKeepalive bool
+
+	// maximum keep-alive connection lifetime, default 0 (unlimited)
+	MaxKeepaliveDuration time.Duration
+
+	// enable tcp keep-alive periods, default false
+	TCPKeepalive bool
+
+	// tcp keep-alive period, default 0 (os default)
+	TCPKeepalivePeriod time.Duration
+
+	// trade cpu usage for memory usage, default false
+	ReduceMemoryUsage bool
+
+	// reject all requests except GET, default false
+	GetOnly bool
+
+	// close all open connections on Stop(), default false
+	CloseOnShutdown bool
+}
+
+// Option changes one of the server options
+type Option func(o *Options)
+
+// DefaultOptions returns the options used when no option is given
+func DefaultOptions() Options {
+	return Options{
+		IdleTimeout:        30 * time.Second,
+		MaxRequestBodySize: fasthttp.DefaultMaxRequestBodySize,
+		Concurrency:        fasthttp.DefaultConcurrency,
+		ReadBufferSize:     4096,
+		WriteBufferSize:    4096,
+	}
+}
+
+// WithOptions replaces all options with the given ones
+func WithOptions(opts Options) Option {
+	return func(o *Options) {
+		*o = opts
+	}
+}
+
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *Options) { o.ReadTimeout = d }
+}
+
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *Options) { o.WriteTimeout = d }
+}
+
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *Options) { o.IdleTimeout = d }
+}
+
+func WithMaxRequestBodySize(size int) Option {
+	return func(o *Options) { o.MaxRequestBodySize = size }
+}
+
+func WithConcurrency(n int) Option {
+	return func(o *Options) { o.Concurrency = n }
+}
+
+func WithMaxConnsPerIP(n int) Option {
+	return func(o *Options) { o.MaxConnsPerIP = n }
+}
+
+func WithMaxRequestsPerConn(n int) Option {
+	return func(o *Options) { o.MaxRequestsPerConn = n }
+}
+
+// WithMaxHeaderSize limits the request header size by the read buffer size
+func WithMaxHeaderSize(size int) Option {
+	return func(o *Options) { o.ReadBufferSize = size }
+}
+
+func WithWriteBufferSize(size int) Option {
+	return func(o *Options) { o.WriteBufferSize = size }
+}
+
+// WithKeepalive enables or disables http keep-alive, maxDuration 0 means unlimited lifetime
+func WithKeepalive(enable bool, maxDuration time.Duration) Option {
+	return func(o *Options) {
+		o.DisableKeepalive = !enable
+		o.MaxKeepaliveDuration = maxDuration
+	}
+}
+
+// WithTCPKeepalive enables tcp keep-alive with the given period, period 0 means os default
+func WithTCPKeepalive(period time.Duration) Option {
+	return func(o *Options) {
+		o.TCPKeepalive = true
+		o.TCPKeepalivePeriod = period
+	}
+}
+
+// OptionError describes an invalid server option
+type OptionError struct {
+	Option string // name of the field of Options, e.g. "ReadBufferSize"
+	Msg    string
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("invalid server option %s: %s", e.Option, e.Msg)
+}
+
+// Validate checks the options, the error is an *OptionError describing the first invalid one
+func (o *Options) Validate() error {
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"ReadTimeout", o.ReadTimeout},
+		{"WriteTimeout", o.WriteTimeout},
+		{"IdleTimeout", o.IdleTimeout},
+		{"MaxKeepaliveDuration", o.MaxKeepaliveDuration},
+		{"TCPKeepalivePeriod", o.TCPKeepalivePeriod},
+	}
+	for _, v := range durations {
+		if v.d < 0 {
+			return &OptionError{Option: v.name, Msg: fmt.Sprintf("%s must not be negative", v.d)}
+		}
+	}
+	limits := []struct {
+		name string
+		n    int
+	}{
+		{"MaxRequestBodySize", o.MaxRequestBodySize},
+		{"Concurrency", o.Concurrency},
+		{"MaxConnsPerIP", o.MaxConnsPerIP},
+		{"MaxRequestsPerConn", o.MaxRequestsPerConn},
+		{"ReadBufferSize", o.ReadBufferSize},
+		{"WriteBufferSize", o.WriteBufferSize},
+	}
+	for _, v := range limits {
+		if v.n < 0 {
+			return &OptionError{Option: v.name, Msg: fmt.Sprintf("%d must not be negative", v.n)}
+		}
+	}
+	if o.ReadBufferSize > 0 && o.ReadBufferSize < 1024 {
+		return &OptionError{Option: "ReadBufferSize",
+			Msg: fmt.Sprintf("%d is too small to hold request headers, at least 1024", o.ReadBufferSize)}
+	}
+	return nil
+}
+
+// newServer creates the fasthttp server from options
+func (s *Serve) newServer() (*fasthttp.Server, error) {
+	if err := s.opts.Validate(); err != nil {
+		return nil, err
+	}
+	o := s.opts
+	return &fasthttp.Server{
+		Handler:              s.handler,
+		Name:                 o.Name,
+		ReadTimeout:          o.ReadTimeout,
+		WriteTimeout:         o.WriteTimeout,
+		IdleTimeout:          o.IdleTimeout,
+		MaxRequestBodySize:   o.MaxRequestBodySize,
+		Concurrency:          o.Concurrency,
+		MaxConnsPerIP:        o.MaxConnsPerIP,
+		MaxRequestsPerConn:   o.MaxRequestsPerConn,
+		ReadBufferSize:       o.ReadBufferSize,
+		WriteBufferSize:      o.WriteBufferSize,
+		DisableKeepalive:     o.DisableKeepalive,
+		MaxKeepaliveDuration: o.MaxKeepaliveDuration,
+		TCPKeepalive:         o.TCPKeepalive,
+		TCPKeepalivePeriod:   o.TCPKeepalivePeriod,
+		ReduceMemoryUsage:    o.ReduceMemoryUsage,
+		GetOnly:              o.GetOnly,
+		CloseOnShutdown:      o.CloseOnShutdown,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		option string // Option of the expected OptionError, empty if the options are valid
+	}{
+		{"defaults", nil, ""},
+		{"limits", []Option{WithReadTimeout(time.Second), WithMaxHeaderSize(8192), WithConcurrency(10)}, ""},
+		{"no read buffer size", []Option{WithMaxHeaderSize(0)}, ""},
+		{"negative read timeout", []Option{WithReadTimeout(-time.Second)}, "ReadTimeout"},
+		{"negative idle timeout", []Option{WithIdleTimeout(-time.Second)}, "IdleTimeout"},
+		{"negative keepalive duration", []Option{WithKeepalive(true, -time.Second)}, "MaxKeepaliveDuration"},
+		{"negative body size", []Option{WithMaxRequestBodySize(-1)}, "MaxRequestBodySize"},
+		{"negative conns per ip", []Option{WithMaxConnsPerIP(-1)}, "MaxConnsPerIP"},
+		{"small header size", []Option{WithMaxHeaderSize(100)}, "ReadBufferSize"},
+		{"replaced options", []Option{WithOptions(Options{WriteBufferSize: -1})}, "WriteBufferSize"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+
+			err := opts.Validate()
+			if tt.option == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			oe, ok := err.(*OptionError)
+			if !ok {
+				t.Fatalf("error was %v; want an *OptionError", err)
+			}
+			if oe.Option != tt.option {
+				t.Errorf("option was %s; want %s", oe.Option, tt.option)
+			}
+		})
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	s := newTestServe(WithMaxHeaderSize(8192), WithKeepalive(false, 0), WithMaxConnsPerIP(3))
+	serv, err := s.newServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serv.ReadBufferSize != 8192 || !serv.DisableKeepalive || serv.MaxConnsPerIP != 3 {
+		t.Errorf("server was %+v; want the options", serv)
+	}
+	if serv.Name != "" {
+		t.Errorf("name was %q; want the fasthttp default", serv.Name)
+	}
+	if serv.IdleTimeout != 30*time.Second {
+		t.Errorf("idle timeout was %s; want the default 30s", serv.IdleTimeout)
+	}
+
+	s = newTestServe(WithMaxHeaderSize(100))
+	if err := s.Start(); err == nil {
+		t.Error("server with invalid options started")
+	} else if _, ok := err.(*OptionError); !ok {
+		t.Errorf("error was %v; want an *OptionError", err)
+	}
+}
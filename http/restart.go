@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// environment variables used to hand listener over to the restarted process
+	envListenFd = "CERA_LISTEN_FD"
+	envReadyFd  = "CERA_READY_FD"
+
+	defaultReadyTimeout = 30 * time.Second
+)
+
+var ErrNotServing = errors.New("server is not serving, nothing to restart")
+
+// EnableGracefulRestart restart the server with Restart() when one of the signals received,
+// SIGHUP is used if no signal given
+func (s *Serve) EnableGracefulRestart(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	s.restartSignals = sigs
+}
+
+// SetReadyTimeout set how long Restart() waits for the new process to be ready, default 30s
+func (s *Serve) SetReadyTimeout(sec int) {
+	s.readyTimeout = time.Duration(sec) * time.Second
+}
+
+// watchRestartSignal calls Restart() when a restart signal received
+func (s *Serve) watchRestartSignal() {
+	if len(s.restartSignals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, s.restartSignals...)
+	go func() {
+		for range ch {
+			if err := s.Restart(); err == nil {
+				signal.Stop(ch)
+				return
+			}
+		}
+	}()
+}
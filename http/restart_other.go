@@ -0,0 +1,25 @@
+//go:build !unix
+
+package http
+
+import (
+	"errors"
+	"net"
+)
+
+var ErrRestartNotSupported = errors.New("graceful restart is only supported on unix systems")
+
+// Restart returns ErrRestartNotSupported, listening sockets can only be handed over
+// to a new process on unix systems
+func (s *Serve) Restart() error {
+	if s.ln == nil {
+		return ErrNotServing
+	}
+	return ErrRestartNotSupported
+}
+
+func inheritedListener() (net.Listener, bool, error) {
+	return nil, false, nil
+}
+
+func notifyReady() {}
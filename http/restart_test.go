@@ -0,0 +1,108 @@
+//go:build unix
+
+package http
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestInheritedListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// the listener gets its own fd as the handed over fd is closed by inheritedListener
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	notSocket, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notSocket.Close()
+	dup := func(f *os.File) string {
+		fd, err := syscall.Dup(int(f.Fd()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strconv.Itoa(fd)
+	}
+
+	tests := []struct {
+		name      string
+		env       string
+		inherited bool
+		ok        bool
+	}{
+		{"not restarted", "", false, false},
+		{"handed over", dup(f), true, true},
+		{"invalid fd", "three", true, false},
+		{"not a socket", dup(notSocket), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				setEnv(t, map[string]string{envListenFd: tt.env})
+			}
+
+			got, inherited, err := inheritedListener()
+			if inherited != tt.inherited {
+				t.Errorf("inherited was %t; want %t", inherited, tt.inherited)
+			}
+			if (err == nil) != (tt.ok || !tt.inherited) {
+				t.Errorf("error was %v", err)
+			}
+			if tt.ok {
+				if got == nil || got.Addr().String() != ln.Addr().String() {
+					t.Errorf("listener was %v; want one on %s", got, ln.Addr())
+				} else {
+					got.Close()
+				}
+			}
+			if v, ok := os.LookupEnv(envListenFd); ok {
+				t.Errorf("%s=%s is still set", envListenFd, v)
+			}
+		})
+	}
+}
+
+func TestRestartErrors(t *testing.T) {
+	s := newTestServe()
+	if err := s.Restart(); err != ErrNotServing {
+		t.Errorf("error of a server not serving was %v; want %v", err, ErrNotServing)
+	}
+
+	s.ln = &noFileListener{}
+	if err := s.Restart(); err == nil || !strings.Contains(err.Error(), "can not be handed over") {
+		t.Errorf("error of a listener without file was %v; want it can't be handed over", err)
+	}
+}
+
+func TestChildEnv(t *testing.T) {
+	setEnv(t, map[string]string{envListenFd: "3", envReadyFd: "4", "CERA_TEST_KEEP": "1"})
+
+	keep := false
+	for _, kv := range childEnv() {
+		if strings.HasPrefix(kv, envListenFd+"=") || strings.HasPrefix(kv, envReadyFd+"=") {
+			t.Errorf("child env has %s", kv)
+		}
+		keep = keep || kv == "CERA_TEST_KEEP=1"
+	}
+	if !keep {
+		t.Error("child env lost CERA_TEST_KEEP")
+	}
+}
+
+// noFileListener is a listener whose file can't be handed over
+type noFileListener struct {
+	net.Listener
+}
@@ -0,0 +1,143 @@
+//go:build unix
+
+package http
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filer is implemented by *net.TCPListener and *net.UnixListener
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Restart starts a new process of the current binary with the same arguments and hands the
+// listening socket over to it. Once the new process is ready to accept connections the
+// current server is shut down gracefully, open connections are drained and Start() returns nil,
+// so the caller's main function can return.
+// If the new process fails to get ready the current server keeps serving and an error is returned.
+func (s *Serve) Restart() error {
+	if s.ln == nil {
+		return ErrNotServing
+	}
+	fl, ok := s.ln.(filer)
+	if !ok {
+		return fmt.Errorf("listener %T can not be handed over", s.ln)
+	}
+	// the socket file of an unix listener should stay for the new process
+	if ul, ok := s.ln.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	lnFile, err := fl.File()
+	if err != nil {
+		return err
+	}
+	defer lnFile.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		readyW.Close()
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// ExtraFiles[i] becomes fd 3+i in the new process
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(childEnv(), envListenFd+"=3", envReadyFd+"=4")
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return err
+	}
+	// only the child holds the write side now, reading returns EOF if the child exits
+	readyW.Close()
+	s.logger.Infof("restarting, new process %d started", cmd.Process.Pid)
+
+	timeout := s.readyTimeout
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process was not ready in %s", timeout)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		go cmd.Wait()
+		if ul, ok := s.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(true)
+		}
+		s.logger.Errorf("restart failed, keep serving: %s", err)
+		return err
+	}
+	s.logger.Infof("new process %d is ready, draining connections", cmd.Process.Pid)
+	// the new process is adopted by init once we exit
+	cmd.Process.Release()
+	return s.serv.Shutdown()
+}
+
+// inheritedListener returns the listener handed over by the parent process on restart
+func inheritedListener() (net.Listener, bool, error) {
+	v := os.Getenv(envListenFd)
+	if v == "" {
+		return nil, false, nil
+	}
+	os.Unsetenv(envListenFd)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid %s %q", envListenFd, v)
+	}
+	ln, err := fileListener(uintptr(fd), "inherited")
+	return ln, true, err
+}
+
+// notifyReady tells the parent process this process is serving, it does nothing
+// when the process is not started by Restart()
+func notifyReady() {
+	v := os.Getenv(envReadyFd)
+	if v == "" {
+		return
+	}
+	os.Unsetenv(envReadyFd)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	if f == nil {
+		return
+	}
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// childEnv returns the environment of the current process without handover variables
+func childEnv() []string {
+	env := os.Environ()
+	out := env[:0:0]
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListenFd+"=") || strings.HasPrefix(kv, envReadyFd+"=") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
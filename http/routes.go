@@ -0,0 +1,52 @@
+package http
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/xxxmailk/cera/middlewares"
+	"github.com/xxxmailk/cera/router"
+)
+
+// Routes returns the route table of the router with the middlewares each route passes through,
+// middlewares added by AtLast are suffixed with "(last)"
+func (s *Serve) Routes() []router.RouteInfo {
+	if s.router == nil {
+		return nil
+	}
+	routes := s.router.Routes()
+	for i := range routes {
+		path := routes[i].Path
+		for _, m := range s.middleWares {
+			if attached(m, path) {
+				routes[i].Middlewares = append(routes[i].Middlewares, middlewareName(m))
+			}
+		}
+		for _, m := range s.lastFunc {
+			if attached(m, path) {
+				routes[i].Middlewares = append(routes[i].Middlewares, middlewareName(m)+"(last)")
+			}
+		}
+	}
+	return routes
+}
+
+// DumpRoutes writes the route table to w, format is "table" or "json", e.g. to print the
+// routes by a command line flag of the application instead of starting the server:
+//     if *routes {
+//         return s.DumpRoutes(os.Stdout, "table")
+//     }
+func (s *Serve) DumpRoutes(w io.Writer, format string) error {
+	return router.DumpRoutes(w, format, s.Routes())
+}
+
+func attached(m middlewares.MiddlewareInterface, path string) bool {
+	if sk, ok := m.(middlewares.PathSkipper); ok {
+		return !sk.SkipPath(path)
+	}
+	return true
+}
+
+func middlewareName(m middlewares.MiddlewareInterface) string {
+	return reflect.TypeOf(m).String()
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/middlewares"
+	"github.com/xxxmailk/cera/router"
+)
+
+// apiOnly is a middleware skipping paths outside /api
+type apiOnly struct {
+	middlewares.Middleware
+}
+
+func (m *apiOnly) SkipPath(path string) bool {
+	return !strings.HasPrefix(path, "/api/")
+}
+
+type audit struct {
+	middlewares.Middleware
+}
+
+func TestRoutes(t *testing.T) {
+	s := newTestServe()
+	if routes := s.Routes(); routes != nil {
+		t.Errorf("routes without router were %v; want none", routes)
+	}
+
+	r := router.New()
+	r.HandleFunc(fasthttp.MethodGet, "/", func(ctx *fasthttp.RequestCtx) {})
+	r.HandleFunc(fasthttp.MethodGet, "/api/users", func(ctx *fasthttp.RequestCtx) {})
+	s.SetRouter(r)
+	s.UseMiddleWare(&apiOnly{})
+	s.AtLast(&audit{})
+
+	want := map[string][]string{
+		"/":          {"*http.audit(last)"},
+		"/api/users": {"*http.apiOnly", "*http.audit(last)"},
+	}
+	for _, rt := range s.Routes() {
+		if !reflect.DeepEqual(rt.Middlewares, want[rt.Path]) {
+			t.Errorf("middlewares of %s were %q; want %q", rt.Path, rt.Middlewares, want[rt.Path])
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := s.DumpRoutes(buf, "table"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "*http.apiOnly, *http.audit(last)") {
+		t.Errorf("table doesn't list the middlewares of /api/users\n%s", buf)
+	}
+	if err := s.DumpRoutes(buf, "xml"); err == nil {
+		t.Error("routes were dumped in an unknown format")
+	}
+}
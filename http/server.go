@@ -6,10 +6,13 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"github.com/valyala/fasthttp"
 	"github.com/xxxmailk/cera/log"
 	"github.com/xxxmailk/cera/middlewares"
 	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+	"io"
 	"math/big"
 	"net"
 	"os"
@@ -21,6 +24,19 @@ type StartHttpServer interface {
 	SetHostname(hostname string)
 	SetRouter(handler *router.Router)
 	SetIdleTimeout(sec int)
+	SetOptions(opts ...Option)
+	SetListener(ln net.Listener)
+	SetUnixSocket(path string, mode os.FileMode)
+	SetUnixSocketOwner(uid, gid int)
+	UseSystemdSocket(name string)
+	EnableGracefulRestart(sigs ...os.Signal)
+	SetReadyTimeout(sec int)
+	SetDebug(debug bool)
+	EnableBatch(path string, opts router.BatchOptions) *router.Route
+	SetMode(m Mode)
+	Restart() error
+	Routes() []router.RouteInfo
+	DumpRoutes(w io.Writer, format string) error
 	Start() error
 	Stop() error
 	UseMiddleWare(m middlewares.MiddlewareInterface)
@@ -32,6 +48,19 @@ type StartTlsServer interface {
 	SetHostname(hostname string)
 	SetRouter(handler *router.Router)
 	SetIdleTimeout(sec int)
+	SetOptions(opts ...Option)
+	SetListener(ln net.Listener)
+	SetUnixSocket(path string, mode os.FileMode)
+	SetUnixSocketOwner(uid, gid int)
+	UseSystemdSocket(name string)
+	EnableGracefulRestart(sigs ...os.Signal)
+	SetReadyTimeout(sec int)
+	SetDebug(debug bool)
+	EnableBatch(path string, opts router.BatchOptions) *router.Route
+	SetMode(m Mode)
+	Restart() error
+	Routes() []router.RouteInfo
+	DumpRoutes(w io.Writer, format string) error
 	StartTls() error
 	Stop() error
 	AtLast(m middlewares.MiddlewareInterface)
@@ -41,7 +70,7 @@ type StartTlsServer interface {
 type Serve struct {
 	ip          string
 	port        string
-	idleTimeout time.Duration
+	opts        Options
 	hostname    string
 	logger      log.SimpleLogger
 	handler     fasthttp.RequestHandler
@@ -51,10 +80,41 @@ type Serve struct {
 	middleWares []middlewares.MiddlewareInterface
 	lastFunc    []middlewares.MiddlewareInterface
 	serv        *fasthttp.Server
+
+	// switches of the mode, see mode.go
+	mode           Mode
+	debug          bool // answer panics with the debug error page, see debug.go
+	logRouting     bool
+	selfSigned     bool
+	strictHeaders  bool
+	templateReload bool
+	tls            bool
+
+	// listener settings, see listener.go
+	listener        net.Listener
+	unixSocket      string
+	unixSocketMode  os.FileMode
+	unixSocketChown bool
+	unixSocketUid   int
+	unixSocketGid   int
+	systemd         bool
+	systemdName     string
+
+	// graceful restart settings, see restart.go
+	ln             net.Listener
+	restartSignals []os.Signal
+	readyTimeout   time.Duration
 }
 
 func (s *Serve) SetIdleTimeout(sec int) {
-	s.idleTimeout = time.Duration(sec) * time.Second
+	s.opts.IdleTimeout = time.Duration(sec) * time.Second
+}
+
+// SetOptions changes server options, it should be called before Start()
+func (s *Serve) SetOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
 }
 
 func (s *Serve) SetHostname(hostname string) {
@@ -80,39 +140,56 @@ func (s *Serve) SetRouter(handler *router.Router) {
 	s.router = handler
 }
 
-func (s *Serve) Start() error {
+// setup creates the fasthttp server and prepares the router, Start and StartTls
+// call it before listening
+func (s *Serve) setup() error {
 	s.SetHandle(s.httpHandler)
-	s.serv = &fasthttp.Server{
-		// allocation http handle with domain name
-		Handler:     s.handler,
-		IdleTimeout: s.idleTimeout,
+	serv, err := s.newServer()
+	if err != nil {
+		s.logger.Errorf(err.Error())
+		return err
 	}
+	s.serv = serv
 	if s.router == nil {
 		panic("please set router before server start server")
 	}
 	s.router.Logger = s.logger
-	s.logger.Infof("starting web server and listening on %s:%s", s.ip, s.port)
+	if s.router.PanicHandler == nil {
+		s.router.PanicHandler = s.panicHandler
+	}
+	s.applyMode()
+	return nil
+}
+
+func (s *Serve) Start() error {
+	if err := s.setup(); err != nil {
+		return err
+	}
 	return s.ListenAndServe()
 }
 
 func (s *Serve) ListenAndServe() error {
-	addr := net.JoinHostPort(s.ip, s.port)
-	ln, err := net.Listen("tcp", addr)
+	ln, err := s.listen()
 	if err != nil {
 		return err
 	}
+	s.logger.Infof("starting web server and listening on %s", ln.Addr())
 	return s.serv.Serve(ln)
 }
 
 func (s *Serve) StartTls() error {
-	s.SetHandle(s.httpHandler)
-	server := &fasthttp.Server{
-		// allocation http handle with domain name
-		Handler:     s.handler,
-		IdleTimeout: s.idleTimeout,
+	if err := s.setup(); err != nil {
+		return err
 	}
 
+	s.tls = true
+
 	if s.sslCert == "" && s.sslKey == "" {
+		if !s.selfSigned {
+			err := fmt.Errorf("tls cert and key are required in %s mode, self-signed certs are not generated", s.mode)
+			s.logger.Errorf(err.Error())
+			return err
+		}
 
 		// preparing second host
 		cert, priv, err := GenerateCert(net.JoinHostPort("127.0.0.1", s.port))
@@ -129,25 +206,27 @@ func (s *Serve) StartTls() error {
 			return err
 		}
 
-		err = server.AppendCertEmbed(cert, priv)
+		ln, err := s.listen()
 		if err != nil {
 			s.logger.Errorf(err.Error())
 			return err
 		}
-		if s.router == nil {
-			panic("please set router before server start server")
-		}
-		s.logger.Infof("starting TLS web server and listening on %s:%s", s.ip, s.port)
-		err = server.ListenAndServeTLS(s.port, "", "")
+		s.logger.Infof("starting TLS web server and listening on %s", ln.Addr())
+		err = s.serv.ServeTLSEmbed(ln, cert, priv)
 		if err != nil {
 			s.logger.Errorf(err.Error())
 		}
 		return err
 	}
-	s.logger.Infof("starting TLS web server and listening on %s:%s", s.ip, s.port)
 
 	// if ssl cert and ssl key had been set, use cert and key file to start ssl server
-	err := server.ListenAndServeTLS(s.port, s.sslCert, s.sslKey)
+	ln, err := s.listen()
+	if err != nil {
+		s.logger.Errorf(err.Error())
+		return err
+	}
+	s.logger.Infof("starting TLS web server and listening on %s", ln.Addr())
+	err = s.serv.ServeTLS(ln, s.sslCert, s.sslKey)
 	if err != nil {
 		s.logger.Fatalf(err.Error())
 		return err
@@ -157,36 +236,49 @@ func (s *Serve) StartTls() error {
 
 // new simple http server
 // you can set your http server before start()
-func NewHttpServe(ip, port string) StartHttpServer {
+// server limits could be set with options, see DefaultOptions() for defaults
+func NewHttpServe(ip, port string, opts ...Option) StartHttpServer {
 	l := log.NewSimpleLogger()
 	host, err := os.Hostname()
 	if err != nil {
 		l.Fatalf(err.Error())
 	}
 	s := &Serve{
-		ip:          ip,
-		port:        port,
-		hostname:    host,
-		idleTimeout: time.Duration(30) * time.Second,
-		logger:      l,
+		ip:       ip,
+		port:     port,
+		hostname: host,
+		opts:     DefaultOptions(),
+		logger:   l,
 	}
+	s.unsetMode()
+	s.SetOptions(opts...)
+	return s
+}
+
+// new http server listening on an unix domain socket,
+// the stale socket file left by a crashed process is removed before listening
+func NewUnixServe(socketPath string, mode os.FileMode, opts ...Option) StartHttpServer {
+	s := NewHttpServe("", "", opts...).(*Serve)
+	s.SetUnixSocket(socketPath, mode)
 	return s
 }
 
 // ditto ↑
-func NewTLSServe(ip, port string) StartTlsServer {
+func NewTLSServe(ip, port string, opts ...Option) StartTlsServer {
 	l := log.NewSimpleLogger()
 	host, err := os.Hostname()
 	if err != nil {
 		l.Fatalf(err.Error())
 	}
 	s := &Serve{
-		ip:          ip,
-		port:        port,
-		hostname:    host,
-		idleTimeout: time.Duration(30) * time.Second,
-		logger:      l,
+		ip:       ip,
+		port:     port,
+		hostname: host,
+		opts:     DefaultOptions(),
+		logger:   l,
 	}
+	s.unsetMode()
+	s.SetOptions(opts...)
 	return s
 }
 
@@ -239,16 +331,48 @@ func GenerateCert(host string) ([]byte, []byte, error) {
 }
 
 func (s *Serve) UseMiddleWare(m middlewares.MiddlewareInterface) {
+	s.setErrorRenderer(m)
 	s.middleWares = append(s.middleWares, m)
 }
 
 func (s *Serve) AtLast(m middlewares.MiddlewareInterface) {
+	s.setErrorRenderer(m)
 	s.lastFunc = append(s.lastFunc, m)
 }
 
+// setErrorRenderer lets middlewares render errors by the router, see middlewares.ErrorRendering
+func (s *Serve) setErrorRenderer(m middlewares.MiddlewareInterface) {
+	if er, ok := m.(middlewares.ErrorRendering); ok {
+		er.SetErrorRenderer(s.renderError)
+	}
+}
+
+// renderError renders errors of middlewares by the router set when the request is served
+func (s *Serve) renderError(ctx *fasthttp.RequestCtx, err *view.HTTPError) {
+	if s.router == nil {
+		ctx.Error(err.Message, err.Status)
+		return
+	}
+	s.router.RenderError(ctx, err, nil)
+}
+
+// EnableBatch registers the batch endpoint of the router at path, see router.Router.Batch.
+// Sub-requests pass through the middlewares like requests of their own, e.g. auth
+// middlewares authorize each of them, opts.Handler is ignored. It must be called after SetRouter.
+func (s *Serve) EnableBatch(path string, opts router.BatchOptions) *router.Route {
+	if s.router == nil {
+		panic("the router must be set before enabling the batch endpoint")
+	}
+	opts.Handler = s.httpHandler
+
+	return s.router.Batch(path, opts)
+}
+
 func (s *Serve) httpHandler(ctx *fasthttp.RequestCtx) {
-	if err := recover(); err != nil {
-		panic(err)
+	defer s.recover(ctx)
+
+	if s.strictHeaders {
+		s.setSecurityHeaders(ctx)
 	}
 
 	// handling middleWares
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+)
+
+// newTestServe returns a server logging nothing
+func newTestServe(opts ...Option) *Serve {
+	l := logrus.New()
+	l.Out = ioutil.Discard
+	s := NewHttpServe("127.0.0.1", "0", opts...).(*Serve)
+	s.SetLogger(l)
+	return s
+}
+
+// serve serves a GET request of path by the server like Start does without listening
+func serve(t *testing.T, s *Serve, r *router.Router, path string) *fasthttp.RequestCtx {
+	t.Helper()
+	s.SetRouter(r)
+	if err := s.setup(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(path)
+	s.httpHandler(ctx)
+	return ctx
+}
+
+// tempDir returns a temporary directory removed when the test ends
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cera-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// setEnv sets the environment variables until the test ends
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+		k := k
+		t.Cleanup(func() { os.Unsetenv(k) })
+	}
+}
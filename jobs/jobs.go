@@ -0,0 +1,417 @@
+// Package jobs runs long-running operations of requests in the background.
+//
+// A view starts the operation as a job, the request is answered with 202 Accepted and the
+// Location of the status resource of the job, which clients poll for its progress, result
+// or error and DELETE to cancel it:
+//
+//	exports := jobs.New(r, "/exports/jobs", jobs.Options{Workers: 4})
+//
+//	func (e *Export) Post() {
+//	    job, err := exports.Start(e.GetCtx(), func(ctx context.Context, job *jobs.Job) (interface{}, error) {
+//	        for i, table := range tables {
+//	            if err := export(ctx, table); err != nil {
+//	                return nil, err
+//	            }
+//	            job.SetProgress(100*(i+1)/len(tables), table)
+//	        }
+//	        return map[string]string{"url": "/exports/42.zip"}, nil
+//	    })
+//	    if err != nil {
+//	        e.Fail(err)
+//	        return
+//	    }
+//	    e.SetBody(job.Status())
+//	}
+//
+// Finished jobs expire after the TTL, their status resource is answered with 404 Not Found then.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/log"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+// Func is the operation of a job, it should return when ctx is canceled. The result is
+// rendered as JSON by the status resource, errors which are no view.HTTPError are
+// rendered as 500 Internal Server Error.
+type Func func(ctx context.Context, job *Job) (result interface{}, err error)
+
+// State is the state of a job
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCanceled  State = "canceled"
+)
+
+// Finished reports whether the job won't change anymore
+func (s State) Finished() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCanceled
+}
+
+// Options are the settings of the worker pool of a Manager
+type Options struct {
+	Workers int           // number of jobs run concurrently, the number of cpus if it's 0
+	Queue   int           // number of jobs waiting for a worker, 100 if it's 0
+	TTL     time.Duration // time finished jobs are kept, 1 hour if it's 0
+
+	// Logger logs failed jobs, the logger the router has when a job fails if it's nil,
+	// which is set by the server on Start, or a simple logger if the router has none
+	Logger log.SimpleLogger
+}
+
+// Status is the status resource of a job
+type Status struct {
+	ID       string          `json:"id"`
+	State    State           `json:"state"`
+	Progress int             `json:"progress"` // percent
+	Message  string          `json:"message,omitempty"`
+	Result   interface{}     `json:"result,omitempty"`
+	Error    *view.HTTPError `json:"error,omitempty"`
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started,omitempty"`
+	Finished *time.Time      `json:"finished,omitempty"`
+}
+
+// Job is a started operation
+type Job struct {
+	fn     Func
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Status returns a copy of the status of the job
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status
+}
+
+// SetProgress sets the progress of the job in percent and a message describing it
+func (j *Job) SetProgress(percent int, message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.status.State.Finished() {
+		j.status.Progress = percent
+		j.status.Message = message
+	}
+}
+
+// finish sets the final state of the job unless it's canceled
+func (j *Job) finish(state State, result interface{}, err *view.HTTPError) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.State.Finished() {
+		return
+	}
+	now := time.Now()
+	j.status.State = state
+	j.status.Result = result
+	j.status.Error = err
+	j.status.Finished = &now
+	if state == StateSucceeded {
+		j.status.Progress = 100
+	}
+}
+
+// Manager runs jobs in a bounded worker pool and serves their status resources
+type Manager struct {
+	path   string
+	opts   Options
+	queue  chan *Job
+	done   chan struct{}
+	wg     sync.WaitGroup
+	router *router.Router
+
+	mu     sync.RWMutex
+	jobs   map[string]*Job
+	closed bool
+}
+
+// New starts the workers of a Manager and registers the status resource of its jobs
+// at path + "/{id}" serving GET, which returns the Status, and DELETE, which cancels
+// queued or running jobs and removes finished jobs.
+func New(r *router.Router, path string, opts Options) *Manager {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Queue <= 0 {
+		opts.Queue = 100
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Hour
+	}
+
+	m := &Manager{
+		path:   strings.TrimRight(path, "/"),
+		opts:   opts,
+		queue:  make(chan *Job, opts.Queue),
+		done:   make(chan struct{}),
+		router: r,
+		jobs:   make(map[string]*Job),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+	go m.expire()
+
+	status := &StatusView{manager: m}
+	r.GET(m.path+"/{id}", status)
+	r.DELETE(m.path+"/{id}", status)
+
+	return m
+}
+
+// Start queues the job and sets the response of the request to 202 Accepted with the
+// Location of the status resource. It fails with 503 Service Unavailable if the queue is full.
+func (m *Manager) Start(ctx *fasthttp.RequestCtx, fn Func) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("generate job id failed, %s", err)
+	}
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		fn:     fn,
+		ctx:    jobCtx,
+		cancel: cancel,
+		status: Status{ID: id, State: StateQueued, Created: time.Now()},
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		cancel()
+		return nil, view.NewHTTPError(fasthttp.StatusServiceUnavailable, "the job manager is closed")
+	}
+	select {
+	case m.queue <- job:
+		m.jobs[id] = job
+	default:
+		m.mu.Unlock()
+		cancel()
+		return nil, view.NewHTTPError(fasthttp.StatusServiceUnavailable, "too many jobs are queued")
+	}
+	m.mu.Unlock()
+
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	ctx.Response.Header.Set(fasthttp.HeaderLocation, m.path+"/"+id)
+	return job, nil
+}
+
+// Job returns the job with the id, nil if there is no such job or it's expired
+func (m *Manager) Job(id string) *Job {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.jobs[id]
+}
+
+// Cancel cancels the job if it's queued or running, ctx of its Func is canceled.
+// It returns false if the job is finished already.
+func (m *Manager) Cancel(job *Job) bool {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.status.State.Finished() {
+		return false
+	}
+	now := time.Now()
+	job.status.State = StateCanceled
+	job.status.Finished = &now
+	job.cancel()
+	return true
+}
+
+// Remove removes the job, it's canceled if it's not finished
+func (m *Manager) Remove(job *Job) {
+	m.Cancel(job)
+
+	m.mu.Lock()
+	delete(m.jobs, job.status.ID)
+	m.mu.Unlock()
+}
+
+// Close cancels the queued and running jobs and stops the workers, jobs can't be started anymore
+func (m *Manager) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.queue)
+	close(m.done)
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mu.Unlock()
+
+	for _, job := range jobs {
+		m.Cancel(job)
+	}
+	m.wg.Wait()
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+
+	for job := range m.queue {
+		m.run(job)
+	}
+}
+
+// run runs the job unless it's canceled while it was queued
+func (m *Manager) run(job *Job) {
+	job.mu.Lock()
+	if job.status.State != StateQueued {
+		job.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	job.status.State = StateRunning
+	job.status.Started = &now
+	job.mu.Unlock()
+
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			m.logError("panic in job %s: %v\n%s", job.status.ID, rcv, debug.Stack())
+			job.finish(StateFailed, nil, view.NewHTTPError(fasthttp.StatusInternalServerError, ""))
+		}
+		job.cancel()
+	}()
+
+	result, err := job.fn(job.ctx, job)
+	if err != nil {
+		e := view.AsHTTPError(err)
+		if e.Status >= fasthttp.StatusInternalServerError {
+			m.logError("job %s failed, %s", job.status.ID, e)
+		}
+		job.finish(StateFailed, nil, e)
+		return
+	}
+	job.finish(StateSucceeded, result, nil)
+}
+
+// expire removes the jobs finished longer than the TTL ago
+func (m *Manager) expire() {
+	interval := m.opts.TTL / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for id, job := range m.jobs {
+				s := job.Status()
+				if s.Finished != nil && now.Sub(*s.Finished) > m.opts.TTL {
+					delete(m.jobs, id)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// logError logs by the logger of the options, else by the logger of the router, which
+// is resolved now as servers set it on Start after the manager is created
+func (m *Manager) logError(format string, args ...interface{}) {
+	logger := m.opts.Logger
+	if logger == nil {
+		logger = m.router.Logger
+	}
+	if logger == nil {
+		logger = log.NewSimpleLogger()
+	}
+	logger.Errorf(format, args...)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// StatusView serves the status resources of the jobs of a Manager
+type StatusView struct {
+	view.ApiView
+	manager *Manager
+}
+
+// DeepCopy shares the manager between requests, router copies views per request
+func (v *StatusView) DeepCopy() interface{} {
+	return &StatusView{manager: v.manager}
+}
+
+// Get renders the status of the job, clients are asked to retry after a second
+// while the job isn't finished
+func (v *StatusView) Get() {
+	job := v.manager.Job(v.Param("id"))
+	if job == nil {
+		v.Fail(view.NewHTTPError(fasthttp.StatusNotFound, "job not found"))
+		return
+	}
+
+	status := job.Status()
+	if !status.State.Finished() {
+		v.GetCtx().Response.Header.Set(fasthttp.HeaderRetryAfter, "1")
+	}
+	v.SetBody(status)
+}
+
+// Delete cancels a queued or running job and renders its status,
+// finished jobs are removed and answered with 204 No Content
+func (v *StatusView) Delete() {
+	job := v.manager.Job(v.Param("id"))
+	if job == nil {
+		v.Fail(view.NewHTTPError(fasthttp.StatusNotFound, "job not found"))
+		return
+	}
+
+	if v.manager.Cancel(job) {
+		v.SetBody(job.Status())
+		return
+	}
+	v.manager.Remove(job)
+	v.GetCtx().SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// Render skips the body of 204 No Content responses
+func (v *StatusView) Render() {
+	if v.GetCtx().Response.StatusCode() == fasthttp.StatusNoContent {
+		return
+	}
+	v.JsonRender()
+}
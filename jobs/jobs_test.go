@@ -0,0 +1,183 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+// blocking returns a Func signaling its start and waiting until it's canceled
+func blocking(started chan<- struct{}) (Func, *bool) {
+	ran := new(bool)
+	return func(ctx context.Context, job *Job) (interface{}, error) {
+		*ran = true
+		if started != nil {
+			started <- struct{}{}
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, ran
+}
+
+func waitState(t *testing.T, job *Job, state State) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for job.Status().State != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("job state is %s; want %s", job.Status().State, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStart(t *testing.T) {
+	r := router.New()
+	m := New(r, "/jobs/", Options{Workers: 1})
+	defer m.Close()
+
+	ctx := &fasthttp.RequestCtx{}
+	job, err := m.Start(ctx, func(ctx context.Context, job *Job) (interface{}, error) {
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := ctx.Response.StatusCode(); status != fasthttp.StatusAccepted {
+		t.Errorf("status was %d; want %d", status, fasthttp.StatusAccepted)
+	}
+	if location, want := string(ctx.Response.Header.Peek(fasthttp.HeaderLocation)), "/jobs/"+job.Status().ID; location != want {
+		t.Errorf("location was %q; want %q", location, want)
+	}
+	waitState(t, job, StateSucceeded)
+	if s := job.Status(); s.Result != "done" || s.Progress != 100 {
+		t.Errorf("status was %+v; want result done and progress 100", s)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		running bool
+	}{
+		{"queued", false},
+		{"running", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(router.New(), "/jobs", Options{Workers: 1})
+			defer m.Close()
+
+			started := make(chan struct{}, 1)
+			first, _ := blocking(started)
+			running, err := m.Start(&fasthttp.RequestCtx{}, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			<-started
+
+			job := running
+			fn, ran := blocking(started)
+			if !tt.running {
+				if job, err = m.Start(&fasthttp.RequestCtx{}, fn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !m.Cancel(job) {
+				t.Fatal("cancel returned false; want true")
+			}
+			if m.Cancel(job) {
+				t.Error("cancel of a canceled job returned true; want false")
+			}
+			if !tt.running {
+				m.Cancel(running)
+				m.Close()
+				if *ran {
+					t.Error("canceled queued job ran")
+				}
+			}
+
+			s := job.Status()
+			if s.State != StateCanceled || s.Finished == nil {
+				t.Errorf("status was %+v; want canceled and finished", s)
+			}
+			if tt.running && s.Started == nil {
+				t.Error("running job has no start time")
+			}
+			if !tt.running && s.Started != nil {
+				t.Error("queued job has a start time")
+			}
+		})
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	m := New(router.New(), "/jobs", Options{Workers: 1, Queue: 1})
+	defer m.Close()
+
+	started := make(chan struct{}, 1)
+	fn, _ := blocking(started)
+	if _, err := m.Start(&fasthttp.RequestCtx{}, fn); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if _, err := m.Start(&fasthttp.RequestCtx{}, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	_, err := m.Start(ctx, fn)
+	if e, ok := err.(*view.HTTPError); !ok || e.Status != fasthttp.StatusServiceUnavailable {
+		t.Fatalf("error was %v; want 503 Service Unavailable", err)
+	}
+	if location := ctx.Response.Header.Peek(fasthttp.HeaderLocation); len(location) != 0 {
+		t.Errorf("rejected job has location %q", location)
+	}
+
+	m.Close()
+	if _, err := m.Start(&fasthttp.RequestCtx{}, fn); err == nil {
+		t.Error("start on a closed manager succeeded")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	r := router.New()
+	m := New(r, "/jobs", Options{Workers: 1, TTL: 20 * time.Millisecond})
+	defer m.Close()
+
+	job, err := m.Start(&fasthttp.RequestCtx{}, func(ctx context.Context, job *Job) (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, job, StateSucceeded)
+	id := job.Status().ID
+
+	get := func() int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/jobs/" + id)
+		r.Handler(ctx)
+		return ctx.Response.StatusCode()
+	}
+	if status := get(); status != fasthttp.StatusOK {
+		t.Fatalf("status of finished job was %d; want %d", status, fasthttp.StatusOK)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for m.Job(id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("finished job didn't expire after the TTL")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := get(); status != fasthttp.StatusNotFound {
+		t.Errorf("status of expired job was %d; want %d", status, fasthttp.StatusNotFound)
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/golang-jwt/jwt"
 	"github.com/valyala/fasthttp"
 
 	"github.com/xxxmailk/cera/log"
 	"github.com/xxxmailk/cera/middlewares"
-	"time"
+	"github.com/xxxmailk/cera/view"
 )
 
 type CeraAuth struct {
@@ -23,6 +26,7 @@ type CeraAuth struct {
 	Resultor    CeraAuthResultor
 	Log         log.SimpleLogger
 	ctx         *fasthttp.RequestCtx
+	render      middlewares.ErrorRenderer
 	middlewares.Middleware
 }
 
@@ -73,6 +77,10 @@ func NewCeraAuth(
 	return c
 }
 
+// authChallenge is the WWW-Authenticate challenge of 401 responses, tokens are sent by the
+// X-Auth-Token header
+const authChallenge = `X-Auth-Token realm="cera"`
+
 type XAuthErr struct {
 	Error string
 }
@@ -97,11 +105,7 @@ func (a *CeraAuth) Handle(ctx *fasthttp.RequestCtx) *fasthttp.RequestCtx {
 	if a.isLoginUrl() {
 		a.Log.Debugf("handle login url %s", a.ctx.URI().Path())
 		if !a.headerAuth() && !a.paramAuth() {
-			a.ctx.SetContentType("application/json")
-			e, _ := json.Marshal(&XAuthErr{Error: "username or password not valid"})
-			a.ctx.SetStatusCode(403)
-			a.ctx.Write(e)
-			a.Break()
+			a.fail("username or password not valid")
 			return ctx
 		} else {
 			a.login()
@@ -112,13 +116,31 @@ func (a *CeraAuth) Handle(ctx *fasthttp.RequestCtx) *fasthttp.RequestCtx {
 		return ctx
 	} else {
 		a.Log.Debugf("login required %s method %s", a.ctx.URI().Path(), a.ctx.Method())
-		e, _ := json.Marshal(&XAuthErr{Error: fmt.Sprintf("auth login required, %s", err)})
+		a.fail(fmt.Sprintf("auth login required, %s", err))
+	}
+	return ctx
+}
+
+// SetErrorRenderer sets the renderer of auth failures, servers set the one of their router
+// so failures are rendered like the errors of views, see middlewares.ErrorRendering
+func (a *CeraAuth) SetErrorRenderer(render middlewares.ErrorRenderer) {
+	a.render = render
+}
+
+// fail answers the request with 401 Unauthorized and the WWW-Authenticate challenge of the
+// X-Auth-Token header and breaks the middleware chain, the error is rendered as XAuthErr if
+// there is no error renderer. Failures were answered with 403 Forbidden before.
+func (a *CeraAuth) fail(msg string) {
+	a.ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, authChallenge)
+	if a.render != nil {
+		a.render(a.ctx, view.NewHTTPError(fasthttp.StatusUnauthorized, msg))
+	} else {
+		e, _ := json.Marshal(&XAuthErr{Error: msg})
 		a.ctx.SetContentType("application/json")
-		a.ctx.SetStatusCode(fasthttp.StatusForbidden)
+		a.ctx.SetStatusCode(fasthttp.StatusUnauthorized)
 		a.ctx.Write(e)
-		a.Break()
 	}
-	return ctx
+	a.Break()
 }
 
 func (a *CeraAuth) headerAuth() bool {
@@ -198,8 +220,13 @@ func (a *CeraAuth) isLoginUrl() bool {
 }
 
 func (a *CeraAuth) ignore() bool {
+	return a.SkipPath(string(a.ctx.Request.URI().Path()))
+}
+
+// SkipPath reports whether the path is in IgnoreUrls
+func (a *CeraAuth) SkipPath(path string) bool {
 	for _, v := range a.IgnoreUrls {
-		if bytes.EqualFold(a.ctx.Request.URI().Path(), []byte(v)) {
+		if strings.EqualFold(path, v) {
 			return true
 		}
 	}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+func TestFail(t *testing.T) {
+	l := logrus.New()
+	l.Out = ioutil.Discard
+
+	tests := []struct {
+		name   string
+		method string
+		uri    string
+		render bool
+		body   string
+	}{
+		{"no token", fasthttp.MethodGet, "/private", false, `{"Error":"auth login required, token contains an invalid number of segments"}`},
+		{"invalid login", fasthttp.MethodPost, "/crea_auth/login", false, `{"Error":"username or password not valid"}`},
+		{"rendered by the router", fasthttp.MethodGet, "/private", true, "rendered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewCeraAuth("user", "secret", "", "key", 3600, nil, l, nil)
+			if tt.render {
+				a.SetErrorRenderer(func(ctx *fasthttp.RequestCtx, err *view.HTTPError) {
+					ctx.SetStatusCode(err.Status)
+					ctx.SetBodyString("rendered")
+				})
+			}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI(tt.uri)
+			a.Handle(ctx)
+
+			if !a.IsBreakHere() {
+				t.Error("failed auth didn't break the middleware chain")
+			}
+			if status := ctx.Response.StatusCode(); status != fasthttp.StatusUnauthorized {
+				t.Errorf("status was %d; want %d", status, fasthttp.StatusUnauthorized)
+			}
+			if challenge := string(ctx.Response.Header.Peek(fasthttp.HeaderWWWAuthenticate)); challenge != authChallenge {
+				t.Errorf("challenge was %q; want %q", challenge, authChallenge)
+			}
+			if body := string(ctx.Response.Body()); body != tt.body {
+				t.Errorf("body was %q; want %q", body, tt.body)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package middlewares
 
-import "github.com/valyala/fasthttp"
+import (
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
 
 type MiddlewareInterface interface {
 	Handle(ctx *fasthttp.RequestCtx) *fasthttp.RequestCtx
@@ -28,3 +31,19 @@ func (m *Middleware) UnBreak() {
 func (m *Middleware) IsBreakHere() bool {
 	return m.b
 }
+
+// PathSkipper is implemented by middlewares which don't handle some paths,
+// it's used to report the middlewares attached to each route
+type PathSkipper interface {
+	SkipPath(path string) bool
+}
+
+// ErrorRenderer renders an error a middleware answers the request with
+type ErrorRenderer func(ctx *fasthttp.RequestCtx, err *view.HTTPError)
+
+// ErrorRendering is implemented by middlewares answering requests with errors, servers set
+// a renderer rendering them like the errors of views by the router, e.g. as problem details
+// documents if the router has ProblemDetails enabled
+type ErrorRendering interface {
+	SetErrorRenderer(render ErrorRenderer)
+}
@@ -0,0 +1,132 @@
+// Package openapi generates OpenAPI 3 documents from the routes of a router.
+//
+// Path parameters and their regex constraints are taken from the route patterns,
+// request and response bodies are documented by views implementing Documented:
+//
+//	func (a *Article) APIDoc() openapi.Doc {
+//	    return openapi.Doc{
+//	        "GET":  {Summary: "get an article", Response: Article{}},
+//	        "POST": {Summary: "create an article", Request: NewArticle{}, Response: Article{}, Status: 201},
+//	    }
+//	}
+//
+// The document and a browsable html page are served with:
+//
+//	openapi.Serve(r, "/docs", openapi.Info{Title: "articles", Version: "1.0"})
+package openapi
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// Documented is implemented by views to document their operations
+type Documented interface {
+	APIDoc() Doc
+}
+
+// Doc documents the operations of a view keyed by http method
+type Doc map[string]OperationDoc
+
+// OperationDoc documents one method of a view
+type OperationDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+
+	// value or pointer of the struct of query arguments, fields are named by json tags
+	Query interface{}
+
+	// value or pointer of the json request body, nil if no body
+	Request interface{}
+
+	// value or pointer of the json response body, nil if no body
+	Response interface{}
+
+	// status code of the response, default 200
+	Status int
+}
+
+// Info is the metadata of the api
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+type Server struct {
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable of the server url, e.g. a label of a host pattern
+type ServerVariable struct {
+	Default     string `json:"default"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower case http methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+
+	// virtual host of the route, empty for routes served for any host
+	Host string `json:"x-host,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
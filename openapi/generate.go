@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xxxmailk/cera/router"
+)
+
+// methods documented for routes registered with ANY
+var wildMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// schemas of parameters using the default converters of the router
+var converterSchemas = map[string]*Schema{
+	"int":  {Type: "integer", Format: "int64"},
+	"uuid": {Type: "string", Format: "uuid"},
+	"date": {Type: "string", Format: "date"},
+}
+
+// Generate builds the OpenAPI document of the router's own routes, routes of virtual
+// hosts are documented by GenerateHost as they may share paths with other hosts
+func Generate(r *router.Router, info Info) *Document {
+	return generate(r.Routes(), info, nil, "")
+}
+
+// GenerateHost builds the OpenAPI document of the routes of the virtual host registered
+// by Router.Host with the pattern host. The host is the server of the document, labels
+// of host patterns are server variables.
+func GenerateHost(r *router.Router, host string, info Info) *Document {
+	host = strings.ToLower(host)
+	doc := generate(r.Routes(), info, nil, host)
+	doc.Servers = []Server{hostServer(host)}
+	return doc
+}
+
+// generate documents the routes of host, the router's own routes if it's empty.
+// Hosts are compared case-insensitively like requests are matched.
+func generate(routes []router.RouteInfo, info Info, exclude map[string]bool, host string) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+	sc := newSchemas()
+
+	for _, rt := range routes {
+		if !strings.EqualFold(rt.Host, host) || exclude[rt.Path] {
+			continue
+		}
+
+		var ops Doc
+		if d, ok := rt.Handler.(Documented); ok {
+			ops = d.APIDoc()
+		}
+
+		methods := []string{rt.Method}
+		if rt.Method == router.MethodWild {
+			methods = wildMethods
+		}
+
+		for i, path := range openAPIPaths(rt) {
+			item := doc.Paths[path.path]
+			if item == nil {
+				item = make(PathItem)
+				doc.Paths[path.path] = item
+			}
+
+			for _, method := range methods {
+				op := operation(sc, rt, method, path, ops[method])
+				if i > 0 && rt.Name != "" {
+					op.OperationID += "_" + strconv.Itoa(i+1)
+				}
+				key := strings.ToLower(method)
+				if _, ok := item[key]; ok && (rt.Method == router.MethodWild || len(rt.Matchers) > 0) {
+					// routes registered for the method have priority over ANY,
+					// routes without matchers over the ones with matchers
+					continue
+				}
+				item[key] = op
+			}
+		}
+	}
+
+	if len(sc.components) > 0 {
+		doc.Components = &Components{Schemas: sc.components}
+	}
+
+	return doc
+}
+
+// hostServer returns the server of the host pattern, "{tenant:[a-z]+}.example.com" is
+// the url "//{tenant}.example.com" with the variable tenant and "*" labels are numbered
+// variables
+func hostServer(host string) Server {
+	srv := Server{Variables: make(map[string]ServerVariable)}
+	labels := router.HostLabels(host)
+	for i, label := range labels {
+		name := ""
+		switch {
+		case label == "*":
+			name = "label" + strconv.Itoa(i+1)
+		case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}"):
+			name = paramName(label)
+		default:
+			continue
+		}
+		labels[i] = "{" + name + "}"
+		srv.Variables[name] = ServerVariable{Default: name}
+	}
+	srv.URL = "//" + strings.Join(labels, ".")
+	if len(srv.Variables) == 0 {
+		srv.Variables = nil
+	}
+
+	return srv
+}
+
+// openAPIPath is a route path in OpenAPI syntax with its path parameters
+type openAPIPath struct {
+	path   string
+	params []router.ParamInfo
+}
+
+// openAPIPaths converts the route pattern into OpenAPI paths, optional parameters are
+// expanded into a path with and a path without them, as OpenAPI path parameters are required
+func openAPIPaths(rt router.RouteInfo) []openAPIPath {
+	params := make(map[string]router.ParamInfo, len(rt.Params))
+	for _, p := range rt.Params {
+		params[p.Name] = p
+	}
+
+	var paths []openAPIPath
+	pattern := rt.Path
+
+	for {
+		out := openAPIPath{}
+		omitted := false
+		locs := paramLocs(pattern)
+
+		b := strings.Builder{}
+		last := 0
+		for _, loc := range locs {
+			name := paramName(pattern[loc[0]:loc[1]])
+			out.params = append(out.params, params[name])
+			b.WriteString(pattern[last:loc[0]])
+			b.WriteString("{" + name + "}")
+			last = loc[1]
+		}
+		b.WriteString(pattern[last:])
+		out.path = b.String()
+		paths = append([]openAPIPath{out}, paths...)
+
+		// drop the last optional parameter and everything after it
+		for i := len(locs) - 1; i >= 0; i-- {
+			if params[paramName(pattern[locs[i][0]:locs[i][1]])].Optional {
+				pattern = strings.TrimSuffix(pattern[:locs[i][0]], "/")
+				if pattern == "" {
+					pattern = "/"
+				}
+				omitted = true
+				break
+			}
+		}
+		if !omitted {
+			return paths
+		}
+	}
+}
+
+// paramLocs returns the start and end index of each parameter in the pattern,
+// braces are allowed in the regex of a parameter
+func paramLocs(pattern string) [][2]int {
+	var locs [][2]int
+	start, depth := -1, 0
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if start < 0 {
+				start = i
+			} else {
+				depth++
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+			} else if start >= 0 {
+				locs = append(locs, [2]int{start, i + 1})
+				start = -1
+			}
+		}
+	}
+
+	return locs
+}
+
+// paramName returns the name of the "{name?:pattern}" parameter
+func paramName(s string) string {
+	s = s[1 : len(s)-1]
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "?")
+}
+
+func operation(sc *schemas, rt router.RouteInfo, method string, path openAPIPath, od OperationDoc) *Operation {
+	op := &Operation{
+		Tags:        od.Tags,
+		Summary:     od.Summary,
+		Description: od.Description,
+		OperationID: operationID(rt, method, path.path),
+		Deprecated:  od.Deprecated,
+		Host:        rt.Host,
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, p := range path.params {
+		schema := &Schema{Type: "string"}
+		desc := ""
+		switch {
+		case p.CatchAll:
+			desc = "the rest of the path, may contain '/'"
+		case converterSchemas[p.Converter] != nil:
+			*schema = *converterSchemas[p.Converter]
+		case p.Pattern != "":
+			schema.Pattern = "^" + p.Pattern + "$"
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          "path",
+			Description: desc,
+			Required:    true,
+			Schema:      schema,
+		})
+	}
+
+	if od.Query != nil {
+		if qs := sc.of(od.Query); qs != nil {
+			qs = sc.resolve(qs)
+			names := make([]string, 0, len(qs.Properties))
+			for name := range qs.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			required := make(map[string]bool, len(qs.Required))
+			for _, name := range qs.Required {
+				required[name] = true
+			}
+			for _, name := range names {
+				op.Parameters = append(op.Parameters, &Parameter{
+					Name:        name,
+					In:          "query",
+					Description: qs.Properties[name].Description,
+					Required:    required[name],
+					Schema:      qs.Properties[name],
+				})
+			}
+		}
+	}
+
+	if od.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: sc.of(od.Request)},
+			},
+		}
+	}
+
+	status := od.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := &Response{Description: http.StatusText(status)}
+	if od.Response != nil {
+		resp.Content = map[string]MediaType{
+			"application/json": {Schema: sc.of(od.Response)},
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	return op
+}
+
+// resolve returns the component schema referenced by s
+func (s *schemas) resolve(schema *Schema) *Schema {
+	if schema.Ref == "" {
+		return schema
+	}
+	return s.components[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+}
+
+// operationID is the route name with the method, or the method with the path
+func operationID(rt router.RouteInfo, method, path string) string {
+	if rt.Name != "" {
+		return strings.ToLower(method) + "_" + rt.Name
+	}
+
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, path)
+
+	return strings.ToLower(method) + strings.TrimRight(id, "_")
+}
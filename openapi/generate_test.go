@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+var update = flag.Bool("update", false, "update the golden documents in testdata")
+
+type article struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title" doc:"title of the article"`
+	Tags  []string
+	Next  *article `json:"next,omitempty"`
+}
+
+type articleQuery struct {
+	Lang string `json:"lang,omitempty" doc:"language of the article"`
+}
+
+type articleView struct {
+	view.ApiView
+}
+
+func (v *articleView) APIDoc() Doc {
+	return Doc{
+		"GET":  {Summary: "get an article", Query: articleQuery{}, Response: article{}},
+		"POST": {Summary: "create an article", Request: &article{}, Response: article{}, Status: 201},
+	}
+}
+
+type plainView struct {
+	view.ApiView
+}
+
+// golden compares the document with testdata/name, the file is written with -update
+func golden(t *testing.T, name string, doc *Document) {
+	t.Helper()
+	got, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("document of %s was\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	r := router.New()
+	r.GET("/articles/{id:int}", &articleView{}).Name("article")
+	r.POST("/articles", &articleView{})
+	r.GET("/archive/{year:int}/{month?}/{day?}", &plainView{}).Name("archive")
+	r.GET("/codes/{code:[a-z]{3}}", &plainView{})
+	r.GET("/files/{path:*}", &plainView{})
+
+	// routes registered for the method have priority over ANY, in any order
+	r.ANY("/any", &plainView{})
+	r.POST("/any", &articleView{})
+	r.POST("/all", &articleView{})
+	r.ANY("/all", &plainView{})
+
+	// routes without matchers have priority over the ones with matchers
+	r.GET("/feed", &plainView{}).Match(router.Header("Accept", "application/xml"))
+	r.GET("/feed", &articleView{})
+
+	// routes of hosts are documented by GenerateHost
+	r.Host("api.example.com").GET("/status", &plainView{})
+
+	golden(t, "router.json", Generate(r, Info{Title: "articles", Version: "1.0"}))
+}
+
+func TestGenerateHost(t *testing.T) {
+	r := router.New()
+	r.GET("/", &plainView{})
+	r.Host("{tenant:[a-z]{2,}}.*.Example.com").GET("/articles/{id:int}", &articleView{})
+
+	golden(t, "host.json", GenerateHost(r, "{tenant:[a-z]{2,}}.*.example.com", Info{Title: "tenants", Version: "1.0"}))
+}
+
+func TestOpenAPIPaths(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/", []string{"/"}},
+		{"/articles/{id:int}", []string{"/articles/{id}"}},
+		{"/{page?}", []string{"/", "/{page}"}},
+		{"/archive/{year}/{month?}/{day?}", []string{"/archive/{year}", "/archive/{year}/{month}", "/archive/{year}/{month}/{day}"}},
+		{"/codes/{code:[a-z]{3}}/{ext?:(json|xml)}", []string{"/codes/{code}", "/codes/{code}/{ext}"}},
+		{"/v{major}.{minor}/{rest:*}", []string{"/v{major}.{minor}/{rest}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := router.New()
+			r.GET(tt.path, &plainView{})
+
+			var got []string
+			for _, p := range openAPIPaths(r.Routes()[0]) {
+				got = append(got, p.path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths were %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostServer(t *testing.T) {
+	tests := []struct {
+		host string
+		url  string
+		vars []string
+	}{
+		{"api.example.com", "//api.example.com", nil},
+		{"{tenant}.example.com", "//{tenant}.example.com", []string{"tenant"}},
+		{"{v:[0-9]+\\.[0-9]+}.api.example.com", "//{v}.api.example.com", []string{"v"}},
+		{"{env:(dev|prod)}.*.example.com", "//{env}.{label2}.example.com", []string{"env", "label2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			srv := hostServer(tt.host)
+			if srv.URL != tt.url {
+				t.Errorf("url was %q; want %q", srv.URL, tt.url)
+			}
+			if len(srv.Variables) != len(tt.vars) {
+				t.Errorf("variables were %v; want %q", srv.Variables, tt.vars)
+			}
+			for _, name := range tt.vars {
+				if v, ok := srv.Variables[name]; !ok || v.Default != name {
+					t.Errorf("variable %s was %v; want default %q", name, v, name)
+				}
+			}
+		})
+	}
+}
+
+func TestServeCache(t *testing.T) {
+	r := router.New()
+	r.GET("/articles", &plainView{})
+	Serve(r, "/docs", Info{Title: "articles", Version: "1.0"})
+
+	spec := func() map[string]PathItem {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/docs/openapi.json")
+		r.Handler(ctx)
+		if status := ctx.Response.StatusCode(); status != fasthttp.StatusOK {
+			t.Fatalf("status was %d; want %d", status, fasthttp.StatusOK)
+		}
+
+		doc := Document{}
+		if err := json.Unmarshal(ctx.Response.Body(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Paths
+	}
+
+	paths := spec()
+	if _, ok := paths["/articles"]; !ok || len(paths) != 1 {
+		t.Fatalf("paths were %v; want /articles only", paths)
+	}
+
+	generation := r.Generation()
+	r.GET("/tags", &plainView{})
+	if r.Generation() == generation {
+		t.Fatal("generation didn't change by a new route")
+	}
+	if _, ok := spec()["/tags"]; !ok {
+		t.Errorf("route registered after the document was served isn't documented")
+	}
+
+	r.Remove(fasthttp.MethodGet, "/tags")
+	if _, ok := spec()["/tags"]; ok {
+		t.Errorf("removed route is still documented")
+	}
+}
@@ -0,0 +1,203 @@
+package openapi
+
+// pageHTML browses the OpenAPI document, operations could be tried from the page.
+// It has no external dependency so it works offline.
+const pageHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - API</title>
+<style>
+body{font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;margin:0;background:#fafafa;color:#3b4151}
+header{background:#1b1b1b;color:#fff;padding:16px 32px}
+header h1{margin:0;font-size:22px}
+header span{color:#aaa;font-size:13px;margin-left:8px}
+main{max-width:1100px;margin:0 auto;padding:16px 32px}
+h2{border-bottom:1px solid #ddd;padding-bottom:6px;font-size:18px}
+.op{border:1px solid;border-radius:4px;margin:8px 0;background:#fff}
+.op>.sum{display:flex;align-items:center;padding:6px 8px;cursor:pointer}
+.method{min-width:70px;text-align:center;border-radius:3px;color:#fff;font-weight:bold;padding:5px 0;font-size:13px}
+.path{font-family:monospace;font-size:15px;margin:0 10px;font-weight:bold}
+.desc{color:#555;font-size:13px}
+.dep .path{text-decoration:line-through}
+.body{display:none;border-top:1px solid #ddd;padding:8px 16px}
+.open .body{display:block}
+.get{border-color:#61affe}.get .method{background:#61affe}
+.post{border-color:#49cc90}.post .method{background:#49cc90}
+.put{border-color:#fca130}.put .method{background:#fca130}
+.patch{border-color:#50e3c2}.patch .method{background:#50e3c2}
+.delete{border-color:#f93e3e}.delete .method{background:#f93e3e}
+.head,.options,.trace{border-color:#9012fe}.head .method,.options .method,.trace .method{background:#9012fe}
+table{border-collapse:collapse;width:100%;font-size:13px}
+td,th{text-align:left;padding:4px 6px;border-bottom:1px solid #eee;vertical-align:top}
+pre{background:#333;color:#fff;padding:8px;border-radius:4px;overflow:auto;font-size:12px;max-height:400px}
+input,textarea{font-family:monospace;width:100%;box-sizing:border-box}
+button{margin:8px 0;padding:4px 16px;cursor:pointer}
+.req{color:red}
+</style>
+</head>
+<body>
+<header><h1 id="title">{{.Title}}</h1></header>
+<main id="main">loading {{.SpecPath}} ...</main>
+<script>
+(function () {
+  var specPath = {{.SpecPath}};
+  var spec;
+
+  function el(tag, attrs, children) {
+    var e = document.createElement(tag);
+    for (var k in attrs || {}) {
+      if (k === "text") e.textContent = attrs[k]; else e.setAttribute(k, attrs[k]);
+    }
+    (children || []).forEach(function (c) { if (c) e.appendChild(c); });
+    return e;
+  }
+
+  function resolve(s) {
+    var seen = 0;
+    while (s && s.$ref && seen++ < 32) s = spec.components.schemas[s.$ref.split("/").pop()];
+    return s || {};
+  }
+
+  function example(s, depth) {
+    s = resolve(s);
+    if (s.allOf) return example(s.allOf[0], depth);
+    if (depth > 6) return null;
+    switch (s.type) {
+      case "object":
+        if (s.additionalProperties) return {key: example(s.additionalProperties, depth + 1)};
+        var o = {};
+        for (var k in s.properties || {}) o[k] = example(s.properties[k], depth + 1);
+        return o;
+      case "array": return [example(s.items, depth + 1)];
+      case "integer": case "number": return 0;
+      case "boolean": return false;
+      case "string": return s.format === "date-time" ? new Date().toISOString() : "string";
+    }
+    return null;
+  }
+
+  function schemaBlock(content) {
+    if (!content || !content["application/json"]) return null;
+    return el("pre", {text: JSON.stringify(example(content["application/json"].schema, 0), null, 2)});
+  }
+
+  function operation(path, method, op) {
+    var box = el("div", {"class": "op " + method + (op.deprecated ? " dep" : "")});
+    var sum = el("div", {"class": "sum"}, [
+      el("span", {"class": "method", text: method.toUpperCase()}),
+      el("span", {"class": "path", text: path}),
+      el("span", {"class": "desc", text: (op.summary || "") + (op["x-host"] ? "  [host " + op["x-host"] + "]" : "")})
+    ]);
+    sum.onclick = function () { box.classList.toggle("open"); };
+
+    var body = el("div", {"class": "body"});
+    if (op.description) body.appendChild(el("p", {text: op.description}));
+
+    var inputs = {};
+    var params = op.parameters || [];
+    if (params.length) {
+      body.appendChild(el("h4", {text: "Parameters"}));
+      var rows = params.map(function (p) {
+        var input = el("input", {placeholder: p.schema && p.schema.pattern ? p.schema.pattern : ""});
+        inputs[p.in + ":" + p.name] = input;
+        return el("tr", {}, [
+          el("td", {}, [el("b", {text: p.name}), p.required ? el("span", {"class": "req", text: " *"}) : null]),
+          el("td", {text: p.in}),
+          el("td", {text: (p.schema && p.schema.type) || ""}),
+          el("td", {text: p.description || ""}),
+          el("td", {}, [input])
+        ]);
+      });
+      body.appendChild(el("table", {}, [el("tr", {}, ["name", "in", "type", "description", "value"].map(function (h) {
+        return el("th", {text: h});
+      }))].concat(rows)));
+    }
+
+    var reqBody;
+    if (op.requestBody) {
+      body.appendChild(el("h4", {text: "Request body (application/json)"}));
+      var ex = schemaBlock(op.requestBody.content);
+      reqBody = el("textarea", {rows: 8});
+      reqBody.value = ex ? ex.textContent : "";
+      body.appendChild(reqBody);
+    }
+
+    body.appendChild(el("h4", {text: "Responses"}));
+    for (var code in op.responses) {
+      var r = op.responses[code];
+      body.appendChild(el("div", {}, [el("b", {text: code + " "}), el("span", {text: r.description})]));
+      var b = schemaBlock(r.content);
+      if (b) body.appendChild(b);
+    }
+
+    var result = el("pre", {style: "display:none"});
+    var btn = el("button", {text: "Try it out"});
+    btn.onclick = function () {
+      var url = path.replace(/\{([^}]+)\}/g, function (m, name) {
+        var v = inputs["path:" + name] ? inputs["path:" + name].value : "";
+        return name === "filepath" ? v : encodeURIComponent(v);
+      });
+      var q = [];
+      params.forEach(function (p) {
+        var v = inputs[p.in + ":" + p.name].value;
+        if (p.in === "query" && v !== "") q.push(encodeURIComponent(p.name) + "=" + encodeURIComponent(v));
+      });
+      if (q.length) url += "?" + q.join("&");
+      var init = {method: method.toUpperCase(), headers: {}};
+      if (reqBody) {
+        init.body = reqBody.value;
+        init.headers["Content-Type"] = "application/json";
+      }
+      result.style.display = "block";
+      result.textContent = init.method + " " + url + "\n...";
+      fetch(url, init).then(function (resp) {
+        return resp.text().then(function (text) {
+          var h = "";
+          resp.headers.forEach(function (v, k) { h += k + ": " + v + "\n"; });
+          try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) {}
+          result.textContent = init.method + " " + url + "\n" + resp.status + " " + resp.statusText + "\n" + h + "\n" + text;
+        });
+      }).catch(function (e) { result.textContent = String(e); });
+    };
+    body.appendChild(btn);
+    body.appendChild(result);
+
+    box.appendChild(sum);
+    box.appendChild(body);
+    return box;
+  }
+
+  function render() {
+    var main = document.getElementById("main");
+    main.textContent = "";
+    document.getElementById("title").appendChild(el("span", {text: "version " + spec.info.version + "  OpenAPI " + spec.openapi}));
+    if (spec.info.description) main.appendChild(el("p", {text: spec.info.description}));
+
+    var groups = {};
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      var item = spec.paths[path];
+      ["get", "post", "put", "patch", "delete", "head", "options", "trace"].forEach(function (m) {
+        if (!item[m]) return;
+        var tag = (item[m].tags && item[m].tags[0]) || "default";
+        (groups[tag] = groups[tag] || []).push(operation(path, m, item[m]));
+      });
+    });
+    Object.keys(groups).sort().forEach(function (tag) {
+      main.appendChild(el("h2", {text: tag}));
+      groups[tag].forEach(function (op) { main.appendChild(op); });
+    });
+  }
+
+  fetch(specPath).then(function (r) { return r.json(); }).then(function (s) {
+    spec = s;
+    spec.components = spec.components || {schemas: {}};
+    render();
+  }).catch(function (e) {
+    document.getElementById("main").textContent = "failed to load " + specPath + ": " + e;
+  });
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemas builds json schemas of go types, named struct types are put into
+// components and referenced so recursive types are supported
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema of the value v, nil if v is nil
+func (s *schemas) of(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string", Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float", Nullable: nullable}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double", Nullable: nullable}
+	case reflect.String:
+		return &Schema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem()), Nullable: nullable}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem()), Nullable: nullable}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	}
+
+	// interfaces and other kinds accept any value
+	return &Schema{}
+}
+
+// component registers the named struct type and returns its component name
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, used := s.components[name]; used {
+		// same name in different packages
+		name = strings.NewReplacer("/", "_", ".", "_").Replace(t.PkgPath()) + "." + t.Name()
+	}
+
+	s.names[t] = name
+	// placeholder for recursive types
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.structSchema(t)
+
+	return name
+}
+
+func (s *schemas) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, f := range fields(t) {
+		fs := s.schema(f.Type)
+		if desc := f.Tag.Get("doc"); desc != "" {
+			if fs.Ref != "" {
+				// siblings of $ref are ignored, wrap it
+				fs = &Schema{Description: desc, AllOf: []*Schema{fs}}
+			} else {
+				fs.Description = desc
+			}
+		}
+		schema.Properties[f.name] = fs
+		if f.required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+
+	return schema
+}
+
+type field struct {
+	reflect.StructField
+	name     string
+	required bool
+}
+
+// fields returns the json fields of the struct, embedded structs are flattened.
+// A field is required unless it's a pointer or has omitempty, `validate:"required"`
+// or `doc_required:"true"` tags make it required anyway
+func fields(t reflect.Type) []field {
+	var out []field
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			out = append(out, fields(ft)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := false
+		for _, o := range opts[1:] {
+			if o == "omitempty" {
+				omitempty = true
+			}
+		}
+		required := f.Type.Kind() != reflect.Ptr && !omitempty
+		if strings.Contains(f.Tag.Get("validate"), "required") || f.Tag.Get("doc_required") == "true" {
+			required = true
+		}
+
+		out = append(out, field{StructField: f, name: name, required: required})
+	}
+
+	return out
+}
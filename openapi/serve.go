@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"strings"
+	"sync"
+
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
+)
+
+// Serve registers GET routes serving the OpenAPI document of the router at path + "/openapi.json"
+// and a html page browsing it at path. The document is generated on request and cached until
+// routes change, so routes registered, replaced or removed after Serve are documented too.
+// The document of the router's own routes is served, the document of the routes of a virtual
+// host by the query argument host with the pattern of the host, see GenerateHost:
+//
+//	GET /docs/openapi.json?host={tenant}.example.com
+//
+// The page works offline, it doesn't load any external resource.
+func Serve(r *router.Router, path string, info Info) {
+	path = strings.TrimRight(path, "/")
+	specPath := path + "/openapi.json"
+	if path == "" {
+		path = "/"
+	}
+
+	d := &docs{
+		router:  r,
+		info:    info,
+		exclude: map[string]bool{path: true, specPath: true},
+	}
+
+	r.GET(path, &PageView{docs: d, specPath: specPath})
+	r.GET(specPath, &SpecView{docs: d})
+}
+
+// docs generates and caches the documents of the hosts
+type docs struct {
+	router  *router.Router
+	info    Info
+	exclude map[string]bool
+
+	mu         sync.Mutex
+	generation uint64
+	specs      map[string][]byte
+}
+
+// json returns the document of the routes of host, documents are generated again
+// when the routes of the router changed
+func (d *docs) json(host string) ([]byte, error) {
+	generation := d.router.Generation()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.specs == nil || d.generation != generation {
+		d.specs = make(map[string][]byte)
+		d.generation = generation
+	}
+	if spec, ok := d.specs[host]; ok {
+		return spec, nil
+	}
+
+	doc := generate(d.router.Routes(), d.info, d.exclude, host)
+	if host != "" {
+		doc.Servers = []Server{hostServer(host)}
+	}
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || len(doc.Paths) > 0 {
+		// hosts without routes aren't cached, the query could name any host
+		d.specs[host] = spec
+	}
+	return spec, nil
+}
+
+// SpecView serves the OpenAPI document as json
+type SpecView struct {
+	view.View
+	docs *docs
+}
+
+// DeepCopy shares the document between requests, router copies views per request
+func (v *SpecView) DeepCopy() interface{} {
+	return &SpecView{docs: v.docs}
+}
+
+func (v *SpecView) Get() {
+	spec, err := v.docs.json(strings.ToLower(string(v.Ctx.QueryArgs().Peek("host"))))
+	if err != nil {
+		v.Logger.Errorf("generate openapi document failed, %s", err)
+		v.Ctx.Error(err.Error(), 500)
+		return
+	}
+	v.Ctx.SetContentType("application/json; charset=utf-8")
+	v.Ctx.SetBody(spec)
+}
+
+func (v *SpecView) Render() {}
+
+// PageView serves the html page browsing the OpenAPI document
+type PageView struct {
+	view.View
+	docs     *docs
+	specPath string
+}
+
+func (v *PageView) DeepCopy() interface{} {
+	return &PageView{docs: v.docs, specPath: v.specPath}
+}
+
+func (v *PageView) Get() {
+	v.Ctx.SetContentType("text/html; charset=utf-8")
+	err := pageTemplate.Execute(v.Ctx, map[string]string{
+		"Title":    v.docs.info.Title,
+		"SpecPath": v.specPath,
+	})
+	if err != nil {
+		v.Logger.Errorf("render openapi page failed, %s", err)
+	}
+}
+
+func (v *PageView) Render() {}
+
+var pageTemplate = template.Must(template.New("openapi").Parse(pageHTML))
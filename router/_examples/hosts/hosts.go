@@ -4,48 +4,48 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router"
+	"github.com/xxxmailk/cera/view"
 )
 
-// Index is the index handler
-func Index(ctx *fasthttp.RequestCtx) {
-	fmt.Fprint(ctx, "Welcome!\n")
+// Index is the index view
+type Index struct {
+	view.ApiView
 }
 
-// Hello is the Hello handler
-func Hello(ctx *fasthttp.RequestCtx) {
-	fmt.Fprintf(ctx, "hello, %s!\n", ctx.UserValue("name"))
+func (i *Index) Get() {
+	i.Data["hello"] = "world"
 }
 
-// HostSwitch is the host-handler map
-// We need an object that implements the fasthttp.RequestHandler interface.
-// We just use a map here, in which we map host names (with port) to fasthttp.RequestHandlers
-type HostSwitch map[string]fasthttp.RequestHandler
-
-// CheckHost Implement a CheckHost method on our new type
-func (hs HostSwitch) CheckHost(ctx *fasthttp.RequestCtx) {
-	// Check if a http.Handler is registered for the given host.
-	// If yes, use it to handle the request.
-	if handler := hs[string(ctx.Host())]; handler != nil {
-		handler(ctx)
-	} else {
-		// Handle host names for which no handler is registered
-		ctx.Error("Forbidden", 403) // Or Redirect?
-	}
+// Tenant is the view of tenant sub domains
+type Tenant struct {
+	view.ApiView
+}
+
+func (t *Tenant) Get() {
+	t.Data["tenant"] = t.Ctx.UserValue("tenant")
 }
 
 func main() {
 	// Initialize a router as usual
 	r := router.New()
-	r.GET("/", Index)
-	r.GET("/hello/{name}", Hello)
+	r.GET("/", &Index{})
+
+	// match example.com:12345 as example.com
+	r.HostIgnorePort = true
+
+	// routes only served for api.example.com
+	api := r.Host("api.example.com")
+	api.GET("/", &Index{})
+	api.SetNotFound(func(ctx *fasthttp.RequestCtx) {
+		ctx.Error("no such api", fasthttp.StatusNotFound)
+	})
 
-	// Make a new HostSwitch and insert the router (our http handler)
-	// for example.com and port 12345
-	hs := make(HostSwitch)
-	hs["example.com:12345"] = r.Handler
+	// routes served for every sub domain, the sub domain is saved as "tenant" user value
+	tenants := r.Host("{tenant}.example.com")
+	tenants.GET("/", &Tenant{})
 
-	// Use the HostSwitch to listen and serve on port 12345
-	log.Fatal(fasthttp.ListenAndServe(":12345", hs.CheckHost))
+	fmt.Println("listening on :12345")
+	log.Fatal(fasthttp.ListenAndServe(":12345", r.Handler))
 }
@@ -0,0 +1,236 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/valyala/bytebufferpool"
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// user value marking the contexts of sub-requests, batches are not nested
+const batchParam = "$batch"
+
+// BatchOptions are the settings of a batch endpoint, see Router.Batch
+type BatchOptions struct {
+	// MaxRequests is the maximum number of sub-requests of a batch, 20 if it's 0
+	MaxRequests int
+
+	// Parallel is the maximum number of sub-requests served concurrently,
+	// sub-requests are served one after another if it's 0 or 1
+	Parallel int
+
+	// ForwardHeaders are the headers of the batch request copied to each sub-request
+	// unless the sub-request sets them, Authorization and Cookie if it's nil
+	ForwardHeaders []string
+
+	// Handler serves the sub-requests, it's required. It should be the handler of the
+	// server including its middlewares, e.g. auth, as sub-requests are not authorized
+	// otherwise. http.Serve.EnableBatch sets the middleware chain of the server.
+	Handler fasthttp.RequestHandler
+}
+
+// BatchRequest is a sub-request of a batch, a body which is a JSON string is sent as it is,
+// other JSON values are sent as application/json
+type BatchRequest struct {
+	Method  string            `json:"method"` // GET if it's empty
+	Path    string            `json:"path"`   // path and query, e.g. "/articles?page=2"
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is the response to a sub-request, a JSON body is embedded as it is,
+// other bodies are JSON strings. Headers keep all values of repeated headers, e.g. Set-Cookie.
+type BatchResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body,omitempty"`
+}
+
+// Batch registers a POST endpoint serving several requests in one round trip. The body is
+// a JSON array of sub-requests, the response is the JSON array of their responses in the
+// same order:
+//     s.EnableBatch("/batch", router.BatchOptions{Parallel: 4})
+//
+//     POST /batch
+//     [{"method": "GET", "path": "/articles/1"},
+//      {"method": "POST", "path": "/articles", "body": {"title": "batch"}}]
+//
+//     [{"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"id": 1}},
+//      {"status": 201, "headers": {"Location": ["/articles/2"]}, "body": {}}]
+// Each sub-request is served with an own request context by opts.Handler, with the headers
+// of the batch request listed by ForwardHeaders so the middlewares of the handler authorize
+// it like a request of its own. Failed sub-requests don't fail the batch. Batch panics if
+// opts.Handler is nil, servers register the endpoint by http.Serve.EnableBatch.
+func (r *Router) Batch(path string, opts BatchOptions) *Route {
+	if opts.Handler == nil {
+		panic("batch handler must not be nil")
+	}
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = 20
+	}
+	if opts.ForwardHeaders == nil {
+		opts.ForwardHeaders = []string{fasthttp.HeaderAuthorization, fasthttp.HeaderCookie}
+	}
+
+	return r.HandleFunc(fasthttp.MethodPost, path, func(ctx *fasthttp.RequestCtx) {
+		r.serveBatch(ctx, &opts)
+	})
+}
+
+func (r *Router) serveBatch(ctx *fasthttp.RequestCtx, opts *BatchOptions) {
+	root := r.root()
+	if ctx.UserValue(batchParam) != nil {
+		root.RenderError(ctx, view.NewHTTPError(fasthttp.StatusBadRequest, "batches can't be nested"), nil)
+		return
+	}
+
+	var requests []BatchRequest
+	if err := json.Unmarshal(ctx.PostBody(), &requests); err != nil {
+		root.RenderError(ctx, view.NewHTTPError(fasthttp.StatusBadRequest, "the batch must be a JSON array of requests"), nil)
+		return
+	}
+	if len(requests) > opts.MaxRequests {
+		msg := fmt.Sprintf("the batch exceeds %d requests", opts.MaxRequests)
+		root.RenderError(ctx, view.NewHTTPError(fasthttp.StatusRequestEntityTooLarge, msg), nil)
+		return
+	}
+
+	origin := newBatchOrigin(ctx, opts.ForwardHeaders)
+	responses := make([]BatchResponse, len(requests))
+	if opts.Parallel <= 1 {
+		for i := range requests {
+			responses[i] = origin.serve(&requests[i], opts.Handler)
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		sem := make(chan struct{}, opts.Parallel)
+		for i := range requests {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				responses[i] = origin.serve(&requests[i], opts.Handler)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := root.Views.EncodeJSON(buf, responses); err != nil {
+		e := view.NewHTTPError(fasthttp.StatusInternalServerError, "")
+		e.Err = fmt.Errorf("render batch responses to json failed, %s", err)
+		root.handleError(ctx, e, nil)
+		return
+	}
+	ctx.SetContentTypeBytes(view.JSONContentType)
+	ctx.SetBody(buf.B)
+}
+
+// batchOrigin holds what sub-requests take from the batch request, it's read once
+// as the batch request can't be read by sub-requests served concurrently
+type batchOrigin struct {
+	host       []byte
+	tls        bool
+	remoteAddr net.Addr
+	headers    [][2][]byte
+}
+
+func newBatchOrigin(ctx *fasthttp.RequestCtx, forward []string) *batchOrigin {
+	o := &batchOrigin{
+		host:       append([]byte(nil), ctx.Host()...),
+		tls:        ctx.IsTLS(),
+		remoteAddr: ctx.RemoteAddr(),
+	}
+	for _, h := range forward {
+		if v := ctx.Request.Header.Peek(h); len(v) > 0 {
+			o.headers = append(o.headers, [2][]byte{[]byte(h), append([]byte(nil), v...)})
+		}
+	}
+
+	return o
+}
+
+// serve serves the sub-request with a context of its own
+func (o *batchOrigin) serve(br *BatchRequest, handler fasthttp.RequestHandler) BatchResponse {
+	if br.Path == "" || br.Path[0] != '/' {
+		return batchError(fasthttp.StatusBadRequest, "path must begin with '/'")
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	method := strings.ToUpper(br.Method)
+	if method == "" {
+		method = fasthttp.MethodGet
+	}
+	req.Header.SetMethod(method)
+	req.SetRequestURI(br.Path)
+	req.Header.SetHostBytes(o.host)
+	if o.tls {
+		req.URI().SetScheme("https")
+	}
+	for _, h := range o.headers {
+		req.Header.SetBytesKV(h[0], h[1])
+	}
+	// errors of sub-requests are rendered as JSON unless the sub-request accepts others
+	req.Header.Set(fasthttp.HeaderAccept, "application/json")
+
+	if len(br.Body) > 0 && !bytes.Equal(br.Body, []byte("null")) {
+		var s string
+		if json.Unmarshal(br.Body, &s) == nil {
+			req.SetBodyString(s)
+		} else {
+			req.SetBody(br.Body)
+			req.Header.SetContentTypeBytes(view.JSONContentType)
+		}
+	}
+	for k, v := range br.Headers {
+		req.Header.Set(k, v)
+	}
+
+	sub := &fasthttp.RequestCtx{}
+	sub.Init(req, o.remoteAddr, nil)
+	sub.SetUserValue(batchParam, true)
+	handler(sub)
+
+	resp := BatchResponse{Status: sub.Response.StatusCode(), Headers: make(map[string][]string)}
+	sub.Response.Header.VisitAll(func(key, value []byte) {
+		switch k := string(key); k {
+		case fasthttp.HeaderContentLength, fasthttp.HeaderServer, fasthttp.HeaderDate:
+		default:
+			resp.Headers[k] = append(resp.Headers[k], string(value))
+		}
+	})
+
+	body := sub.Response.Body()
+	switch {
+	case len(body) == 0:
+	case bytes.Contains(sub.Response.Header.ContentType(), []byte("json")) && json.Valid(body):
+		resp.Body = append(json.RawMessage(nil), body...)
+	default:
+		resp.Body, _ = json.Marshal(string(body))
+	}
+
+	return resp
+}
+
+// batchError returns the response to an invalid sub-request
+func batchError(status int, message string) BatchResponse {
+	body, _ := json.Marshal(view.NewHTTPError(status, message))
+	return BatchResponse{
+		Status:  status,
+		Headers: map[string][]string{fasthttp.HeaderContentType: {string(view.JSONContentType)}},
+		Body:    body,
+	}
+}
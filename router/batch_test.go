@@ -0,0 +1,136 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// batchRouter returns a router with routes for sub-requests and the batch endpoint
+func batchRouter(opts BatchOptions) *Router {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/articles/{id}", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/json")
+		ctx.SetBodyString(`{"id":` + ctx.UserValue("id").(string) + `}`)
+	})
+	r.HandleFunc(fasthttp.MethodPost, "/articles", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusCreated)
+		ctx.Response.Header.Set("Location", "/articles/2")
+		ctx.Response.Header.SetContentTypeBytes(ctx.Request.Header.ContentType())
+		ctx.SetBody(ctx.PostBody())
+	})
+	r.HandleFunc(fasthttp.MethodGet, "/whoami", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(string(ctx.Request.Header.Peek("Authorization")) + " " + string(ctx.Request.Header.Peek("X-Trace")))
+	})
+
+	opts.Handler = r.Handler
+	r.Batch("/batch", opts)
+	return r
+}
+
+// postBatch posts the batch body to r
+func postBatch(r *Router, body string, headers ...string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/batch")
+	for i := 0; i+1 < len(headers); i += 2 {
+		ctx.Request.Header.Set(headers[i], headers[i+1])
+	}
+	ctx.Request.SetBodyString(body)
+	r.Handler(ctx)
+	return ctx
+}
+
+func TestBatch(t *testing.T) {
+	batch := `[
+		{"path": "/articles/1"},
+		{"method": "post", "path": "/articles", "body": {"title": "batch"}},
+		{"method": "POST", "path": "/articles", "body": "plain text"},
+		{"path": "/whoami", "headers": {"X-Trace": "42"}},
+		{"path": "/missing"},
+		{"method": "DELETE", "path": "/articles/1"},
+		{"path": "articles"},
+		{"method": "POST", "path": "/batch", "body": []}
+	]`
+
+	want := []struct {
+		status int
+		body   string
+	}{
+		{fasthttp.StatusOK, `{"id":1}`},
+		{fasthttp.StatusCreated, `{"title":"batch"}`},
+		{fasthttp.StatusCreated, `"plain text"`},
+		{fasthttp.StatusOK, `"Bearer token 42"`},
+		{fasthttp.StatusNotFound, `{"status":404,"message":"Not Found"}`},
+		{fasthttp.StatusMethodNotAllowed, `{"status":405,"message":"Method Not Allowed"}`},
+		{fasthttp.StatusBadRequest, `{"status":400,"message":"path must begin with '/'"}`},
+		{fasthttp.StatusBadRequest, `{"status":400,"message":"batches can't be nested"}`},
+	}
+
+	for _, parallel := range []int{0, 4} {
+		t.Run("parallel "+strconv.Itoa(parallel), func(t *testing.T) {
+			r := batchRouter(BatchOptions{Parallel: parallel})
+			ctx := postBatch(r, batch, "Authorization", "Bearer token")
+
+			if status := ctx.Response.StatusCode(); status != fasthttp.StatusOK {
+				t.Fatalf("status was %d; want %d: %s", status, fasthttp.StatusOK, ctx.Response.Body())
+			}
+			var responses []BatchResponse
+			if err := json.Unmarshal(ctx.Response.Body(), &responses); err != nil {
+				t.Fatalf("body %s isn't json, %s", ctx.Response.Body(), err)
+			}
+			if len(responses) != len(want) {
+				t.Fatalf("got %d responses; want %d", len(responses), len(want))
+			}
+			for i, resp := range responses {
+				if resp.Status != want[i].status || string(resp.Body) != want[i].body {
+					t.Errorf("response %d was %d %s; want %d %s", i+1, resp.Status, resp.Body, want[i].status, want[i].body)
+				}
+			}
+			if loc := responses[1].Headers["Location"]; !reflect.DeepEqual(loc, []string{"/articles/2"}) {
+				t.Errorf("location was %q; want /articles/2", loc)
+			}
+			if _, ok := responses[0].Headers[fasthttp.HeaderContentLength]; ok {
+				t.Error("content length of a sub-response was kept")
+			}
+		})
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	r := batchRouter(BatchOptions{MaxRequests: 2})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		msg    string
+	}{
+		{"not json", `{`, fasthttp.StatusBadRequest, "the batch must be a JSON array of requests"},
+		{"not an array", `{"path": "/articles/1"}`, fasthttp.StatusBadRequest, "the batch must be a JSON array of requests"},
+		{"too many requests", `[{"path": "/"}, {"path": "/"}, {"path": "/"}]`, fasthttp.StatusRequestEntityTooLarge, "the batch exceeds 2 requests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := postBatch(r, tt.body, "Accept", "application/json")
+			if status := ctx.Response.StatusCode(); status != tt.status {
+				t.Errorf("status was %d; want %d", status, tt.status)
+			}
+			var e struct{ Message string }
+			if err := json.Unmarshal(ctx.Response.Body(), &e); err != nil || e.Message != tt.msg {
+				t.Errorf("body was %s; want message %q", ctx.Response.Body(), tt.msg)
+			}
+		})
+	}
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("batch without handler didn't panic")
+		}
+	}()
+	New().Batch("/batch", BatchOptions{})
+}
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xxxmailk/cera/view"
+)
+
+// DateLayout is the layout of values of the date converter
+const DateLayout = "2006-01-02"
+
+// Converter converts the values of path parameters using it by name, e.g. {id:int}.
+// The value is available as typed value from the view params and ctx.UserValue.
+type Converter struct {
+	// Regex constrains the values matched by routes, it must not contain capturing groups
+	Regex string
+
+	// Convert returns the typed value, an error makes the route not match the request
+	Convert func(value string) (interface{}, error)
+
+	// Format formats typed values for Router.URL, fmt.Sprint is used if it's nil
+	Format func(value interface{}) string
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[string]*Converter{
+		"int": {
+			Regex: `-?[0-9]+`,
+			Convert: func(value string) (interface{}, error) {
+				return strconv.Atoi(value)
+			},
+		},
+		"uuid": {
+			Regex: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+			Convert: func(value string) (interface{}, error) {
+				return strings.ToLower(value), nil
+			},
+		},
+		"slug": {
+			Regex: `[a-z0-9]+(?:-[a-z0-9]+)*`,
+			Convert: func(value string) (interface{}, error) {
+				return value, nil
+			},
+		},
+		"date": {
+			Regex: `[0-9]{4}-[0-9]{2}-[0-9]{2}`,
+			Convert: func(value string) (interface{}, error) {
+				return time.Parse(DateLayout, value)
+			},
+			Format: func(value interface{}) string {
+				if t, ok := value.(time.Time); ok {
+					return t.Format(DateLayout)
+				}
+				return fmt.Sprint(value)
+			},
+		},
+	}
+)
+
+// RegisterConverter registers a converter used by routes with the parameter {name:<name>}.
+// The converters int, uuid, slug and date are registered by default, registering one of
+// them replaces it. Converters must be registered before the routes using them.
+func RegisterConverter(name string, c Converter) {
+	switch {
+	case name == "" || name == "*":
+		panic("invalid converter name '" + name + "'")
+	case c.Convert == nil:
+		panic("converter '" + name + "' has no Convert function")
+	}
+
+	re, err := regexp.Compile(c.Regex)
+	if err != nil {
+		panic(fmt.Sprintf("invalid regex of converter '%s': %s", name, err))
+	}
+	if c.Regex == "" || re.NumSubexp() > 0 {
+		panic("regex of converter '" + name + "' must not be empty or contain capturing groups")
+	}
+
+	convertersMu.Lock()
+	converters[name] = &c
+	convertersMu.Unlock()
+}
+
+// converter returns the converter registered with the name, nil if there is none
+func converter(name string) *Converter {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+
+	return converters[name]
+}
+
+// paramConverter is the converter of a parameter of a route
+type paramConverter struct {
+	key       string
+	converter *Converter
+}
+
+// convertParams converts the values of the params, it returns false if a value
+// can't be converted
+func convertParams(params view.Params, convs []paramConverter) bool {
+	for _, c := range convs {
+		for i := range params {
+			if params[i].Key != c.key {
+				continue
+			}
+
+			v, err := c.converter.Convert(params[i].Value)
+			if err != nil {
+				return false
+			}
+			params[i].Typed = v
+		}
+	}
+
+	return true
+}
+
+// treePath returns the path added to the trees, converter names are replaced by their regex
+func treePath(path string, parts []urlPart) string {
+	expand := false
+	for _, p := range parts {
+		if p.converter != nil {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return path
+	}
+
+	return formatPattern(parts, true)
+}
+
+// formatPattern formats the parsed path pattern, optional marks are only kept if optional is set
+func formatPattern(parts []urlPart, optional bool) string {
+	b := strings.Builder{}
+	for _, p := range parts {
+		if p.key == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+
+		b.WriteString("{" + p.key)
+		if p.optional && optional {
+			b.WriteByte('?')
+		}
+		switch {
+		case p.catchAll:
+			b.WriteString(":*")
+		case p.pattern != "":
+			b.WriteString(":" + p.pattern)
+		}
+		b.WriteByte('}')
+	}
+
+	return b.String()
+}
+
+// paramConverters returns the converters of the parameters
+func paramConverters(parts []urlPart) []paramConverter {
+	var convs []paramConverter
+	for _, p := range parts {
+		if p.converter != nil {
+			convs = append(convs, paramConverter{key: p.key, converter: p.converter})
+		}
+	}
+
+	return convs
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestConverters(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    interface{} // typed value of the param, nil if the route doesn't match
+	}{
+		{"int", "/items/{id:int}", "/items/42", 42},
+		{"negative int", "/items/{id:int}", "/items/-7", -7},
+		{"int overflow", "/items/{id:int}", "/items/99999999999999999999", nil},
+		{"not an int", "/items/{id:int}", "/items/abc", nil},
+		{"date", "/days/{id:date}", "/days/2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"invalid date", "/days/{id:date}", "/days/2023-02-30", nil},
+		{"date format", "/days/{id:date}", "/days/20240229", nil},
+		{"uuid", "/users/{id:uuid}", "/users/0A1B2C3D-4E5F-6789-ABCD-EF0123456789", "0a1b2c3d-4e5f-6789-abcd-ef0123456789"},
+		{"not a uuid", "/users/{id:uuid}", "/users/42", nil},
+		{"slug", "/posts/{id:slug}", "/posts/hello-world", "hello-world"},
+		{"not a slug", "/posts/{id:slug}", "/posts/Hello_World", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			var got interface{}
+			r.HandleFunc(fasthttp.MethodGet, tt.pattern, func(ctx *fasthttp.RequestCtx) {
+				got = ctx.UserValue("id")
+			})
+
+			ctx := serve(r, tt.path)
+			if tt.want == nil {
+				if status := ctx.Response.StatusCode(); status != fasthttp.StatusNotFound {
+					t.Errorf("status was %d; want %d", status, fasthttp.StatusNotFound)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("param was %#v; want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterURL(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/days/{day:date}/items/{id:int}", func(ctx *fasthttp.RequestCtx) {}).Name("item")
+
+	url, err := r.URL("item", "day", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), "id", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/days/2024-02-29/items/7"; url != want {
+		t.Errorf("url was %q; want %q", url, want)
+	}
+}
+
+func TestRegisterConverterPanics(t *testing.T) {
+	tests := []struct {
+		name      string
+		converter Converter
+		register  string
+	}{
+		{"empty name", Converter{Regex: "[a-z]+", Convert: func(v string) (interface{}, error) { return v, nil }}, ""},
+		{"no convert", Converter{Regex: "[a-z]+"}, "test"},
+		{"capturing group", Converter{Regex: "([a-z]+)", Convert: func(v string) (interface{}, error) { return v, nil }}, "test"},
+		{"invalid regex", Converter{Regex: "[a-z", Convert: func(v string) (interface{}, error) { return v, nil }}, "test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterConverter didn't panic")
+				}
+			}()
+			RegisterConverter(tt.register, tt.converter)
+		})
+	}
+}
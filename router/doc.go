@@ -50,6 +50,17 @@ next '/' or the path end:
   /blog/go/                           no match
   /blog/go/request-routers/comments   no match
 
+A segment may contain several named parameters separated by literal chars,
+the first parameters take as much of the segment as possible:
+ Path: /files/{name}.{ext}
+
+ Requests:
+  /files/app.tar.gz                   match: name="app.tar", ext="gz"
+  /files/README                       no match
+
+ Path: /v{major}.{minor}/status     matches /v1.2/status
+ Path: /{lat},{lng}                 matches /52.5,13.4
+
 Catch-all parameters match anything until the path end, including the
 directory index (the '/' before the catch-all). Since they match anything
 until the end, catch-all parameters must always be the final path element.
@@ -66,5 +77,75 @@ each of a key and a value. The slice is passed to the Handle func as a third
 parameter.
 To retrieve the value of a parameter,gets by the name of the parameter
  user := ctx.UserValue("user") // defined by {user} or {user:*}
+
+Views embedding view.View get the parameters as strings without the ctx:
+ user := v.Param("user")
+
+Parameters may use a named converter instead of a regex, the value must match the
+regex of the converter and is converted to a typed value. The converters int, uuid,
+slug and date are built in, others are registered with RegisterConverter:
+ Path: /archive/{day:date}/{id:int}
+
+ id, err := v.ParamInt("id")      // ctx.UserValue("id") is an int
+ day := v.ParamValue("day").(time.Time)
+ err := v.BindParams(&struct{ ID int `param:"id"` }{})
+
+Routes can be bound to a host with Router.Host, which returns a group. Host
+patterns may capture labels like paths capture segments:
+ api := r.Host("api.example.com")
+ api.GET("/status", &Status{})
+
+ tenants := r.Host("{tenant}.example.com")
+ tenants.GET("/", &Home{}) // ctx.UserValue("tenant") is the sub domain
+
+Plain handlers are registered without a view, they take part in the 405 and
+OPTIONS handling like views:
+ r.HandleFunc(fasthttp.MethodGet, "/metrics", metricsHandler)
+ r.HandleHTTP(router.MethodWild, "/legacy/{path:*}", legacyMux) // net/http handler
+
+Routers built independently, e.g. by modules, are composed with Mount:
+ r.Mount("/users", users.Routes())
+
+A batch endpoint serves several requests in one round trip, each sub-request is
+served by the middlewares of the server and the router with a request context of its
+own and the auth headers of the batch:
+ s.EnableBatch("/batch", router.BatchOptions{Parallel: 4})
+
+Views fail with an error by View.Fail or raise one by View.Abort, the error is
+rendered by the ErrorHandler of the router as html for views and as JSON for views
+rendering JSON. Panics and 404, 405 and 501 responses are rendered by it as well:
+ r.ErrorHandler = func(ctx *fasthttp.RequestCtx, err *view.HTTPError, v view.MethodViewer) {
+     router.DefaultErrorHandler(ctx, err, v)
+     metrics.CountError(err.Status)
+ }
+
+With ProblemDetails enabled errors are rendered as application/problem+json documents,
+api views build them with ApiView.Problem and ApiView.ValidationProblem:
+ r.ProblemDetails = true
+
+REST resources map the actions of a view, see view.ResourceView, to the collection
+and item routes, actions the view doesn't implement are answered with 405:
+ r.Resource("/articles", &Articles{}) // GET, POST /articles, GET, PUT, PATCH, DELETE /articles/{id}
+
+Routes may require more than method and path with matchers, which are evaluated
+after the path is found. Routes with more matchers are tried first, the route without
+matchers of the path is tried last:
+ r.POST("/upload", &FormUpload{})
+ r.Match(router.ContentType("application/json")).POST("/upload", &JSONUpload{})
+ r.GET("/feed", &Atom{}).Match(router.Query("atom"), router.Scheme("https"))
+
+API versions are served side by side by version groups, the version of a request is
+selected by path prefix, Accept media type or header as configured by Router.Versioning:
+ r.Versioning = router.Versioning{Vendor: "x", Header: "X-API-Version", Default: "1"}
+ r.Version("1").GET("/articles", &Articles{})
+ r.Version("2").GET("/articles", &ArticlesV2{}) // Accept: application/vnd.x.v2+json
+
+Routes could be added, replaced and removed while requests are served, each change
+is made on a copy of the routing table which then replaces the one in use atomically:
+ r.Replace(fasthttp.MethodGet, "/feature", &NewFeature{})
+ r.Remove(fasthttp.MethodGet, "/beta")
+
+Routes registered before the first request are added to the routing table in place,
+the router is frozen by the first request or by Router.Freeze.
 */
 package router
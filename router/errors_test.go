@@ -0,0 +1,164 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// failingView fails by the "fail" query argument
+type failingView struct {
+	view.ApiView
+}
+
+func (v *failingView) Get() {
+	switch string(v.Ctx.QueryArgs().Peek("fail")) {
+	case "http":
+		v.Fail(&view.HTTPError{Status: fasthttp.StatusNotFound, Code: "article_not_found", Message: "no such article"})
+	case "abort":
+		v.Abort(fasthttp.StatusForbidden, "")
+	case "error":
+		v.Fail(errors.New("database is down"))
+	case "panic":
+		panic("boom")
+	default:
+		v.SetBody("ok")
+	}
+}
+
+// pageView is a view rendering html
+type pageView struct {
+	view.View
+}
+
+func (v *pageView) Get() {
+	v.Abort(fasthttp.StatusGone, "the page is gone")
+}
+
+func TestErrorHandler(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.Out = out
+
+	r := New()
+	r.Logger = l
+	r.GET("/articles", &failingView{})
+	r.GET("/page", &pageView{})
+
+	tests := []struct {
+		name   string
+		uri    string
+		accept string
+		status int
+		body   string // part of the expected body
+		logged bool
+	}{
+		{"ok", "/articles", "", fasthttp.StatusOK, `"ok"`, false},
+		{"http error", "/articles?fail=http", "", fasthttp.StatusNotFound, `{"status":404,"code":"article_not_found","message":"no such article"}`, false},
+		{"abort", "/articles?fail=abort", "", fasthttp.StatusForbidden, `{"status":403,"message":"Forbidden"}`, false},
+		{"error", "/articles?fail=error", "", fasthttp.StatusInternalServerError, `{"status":500,"message":"Internal Server Error"}`, true},
+		{"panic", "/articles?fail=panic", "", fasthttp.StatusInternalServerError, `{"status":500,"message":"Internal Server Error"}`, true},
+		{"html view", "/page", "application/json", fasthttp.StatusGone, "410 Sorry, the page is gone!", false},
+		{"not found as json", "/missing", "application/json", fasthttp.StatusNotFound, `{"status":404,"message":"Not Found"}`, false},
+		{"not found as html", "/missing", "text/html", fasthttp.StatusNotFound, "404 Sorry, Not Found!", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			ctx := serveHeaders(r, fasthttp.MethodGet, tt.uri, "Accept", tt.accept)
+
+			if status := ctx.Response.StatusCode(); status != tt.status {
+				t.Errorf("status was %d; want %d", status, tt.status)
+			}
+			if body := string(ctx.Response.Body()); !strings.Contains(body, tt.body) {
+				t.Errorf("body was %s; want %s", body, tt.body)
+			}
+			if strings.Contains(string(ctx.Response.Body()), "database is down") {
+				t.Error("the cause of the error was exposed")
+			}
+			if logged := out.Len() > 0; logged != tt.logged {
+				t.Errorf("error was logged %t; want %t: %s", logged, tt.logged, out)
+			}
+		})
+	}
+}
+
+func TestCustomErrorHandler(t *testing.T) {
+	r := New()
+	r.GET("/articles", &failingView{})
+
+	var handled []string
+	r.ErrorHandler = func(ctx *fasthttp.RequestCtx, err *view.HTTPError, v view.MethodViewer) {
+		_, isView := v.(*failingView)
+		handled = append(handled, err.Error())
+		if !isView && v != nil {
+			t.Errorf("view of the error was %T", v)
+		}
+		ctx.SetStatusCode(err.Status)
+		ctx.SetBodyString(err.Code)
+	}
+
+	ctx := serve(r, "/articles?fail=http")
+	if body := string(ctx.Response.Body()); body != "article_not_found" {
+		t.Errorf("body was %q; want %q", body, "article_not_found")
+	}
+	serve(r, "/missing")
+
+	want := []string{"404 no such article", "404 Not Found"}
+	if strings.Join(handled, ", ") != strings.Join(want, ", ") {
+		t.Errorf("handled errors were %q; want %q", handled, want)
+	}
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	r := New()
+	r.ProblemDetails = true
+	r.GET("/articles", &failingView{})
+	r.GET("/page", &pageView{})
+
+	tests := []struct {
+		uri         string
+		accept      string
+		contentType string
+	}{
+		{"/articles?fail=http", "", view.ProblemContentType},
+		{"/missing", "", view.ProblemContentType},
+		{"/missing", "text/html", "text/html; charset=utf-8"},
+		{"/page", "application/json", "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri+" "+tt.accept, func(t *testing.T) {
+			ctx := serveHeaders(r, fasthttp.MethodGet, tt.uri, "Accept", tt.accept)
+			if ct := string(ctx.Response.Header.ContentType()); ct != tt.contentType {
+				t.Errorf("content type was %q; want %q", ct, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestAsHTTPError(t *testing.T) {
+	cause := errors.New("database is down")
+	e := view.AsHTTPError(cause)
+	if e.Status != fasthttp.StatusInternalServerError || e.Message != "Internal Server Error" || !errors.Is(e, cause) {
+		t.Errorf("error of a plain error was %+v", e)
+	}
+
+	nf := view.NewHTTPError(fasthttp.StatusNotFound, "")
+	if got := view.AsHTTPError(&wrapped{nf}); got != nf {
+		t.Errorf("error of a wrapped HTTPError was %+v; want %+v", got, nf)
+	}
+}
+
+type wrapped struct {
+	err error
+}
+
+func (w *wrapped) Error() string { return "wrapped: " + w.err.Error() }
+func (w *wrapped) Unwrap() error { return w.err }
@@ -8,49 +8,56 @@ import (
 // Group returns a new group.
 // Path auto-correction, including trailing slashes, is enabled by default.
 func (g *Group) Group(path string) *Group {
-	return g.router.Group(g.prefix + path)
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix + path,
+		matchers:   g.matchers,
+		version:    g.version,
+		base:       g.base + path,
+		unprefixed: g.unprefixed,
+	}
 }
 
 // GET is a shortcut for group.Handle(fasthttp.MethodGet, path, handler)
-func (g *Group) GET(path string, handler view.MethodViewer) {
-	g.router.GET(g.prefix+path, handler)
+func (g *Group) GET(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodGet, path, handler)
 }
 
 // HEAD is a shortcut for group.Handle(fasthttp.MethodHead, path, handler)
-func (g *Group) HEAD(path string, handler view.MethodViewer) {
-	g.router.HEAD(g.prefix+path, handler)
+func (g *Group) HEAD(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodHead, path, handler)
 }
 
 // OPTIONS is a shortcut for group.Handle(fasthttp.MethodOptions, path, handler)
-func (g *Group) OPTIONS(path string, handler view.MethodViewer) {
-	g.router.OPTIONS(g.prefix+path, handler)
+func (g *Group) OPTIONS(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodOptions, path, handler)
 }
 
 // POST is a shortcut for group.Handle(fasthttp.MethodPost, path, handler)
-func (g *Group) POST(path string, handler view.MethodViewer) {
-	g.router.POST(g.prefix+path, handler)
+func (g *Group) POST(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodPost, path, handler)
 }
 
 // PUT is a shortcut for group.Handle(fasthttp.MethodPut, path, handler)
-func (g *Group) PUT(path string, handler view.MethodViewer) {
-	g.router.PUT(g.prefix+path, handler)
+func (g *Group) PUT(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodPut, path, handler)
 }
 
 // PATCH is a shortcut for group.Handle(fasthttp.MethodPatch, path, handler)
-func (g *Group) PATCH(path string, handler view.MethodViewer) {
-	g.router.PATCH(g.prefix+path, handler)
+func (g *Group) PATCH(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodPatch, path, handler)
 }
 
 // DELETE is a shortcut for group.Handle(fasthttp.MethodDelete, path, handler)
-func (g *Group) DELETE(path string, handler view.MethodViewer) {
-	g.router.DELETE(g.prefix+path, handler)
+func (g *Group) DELETE(path string, handler view.MethodViewer) *Route {
+	return g.Handle(fasthttp.MethodDelete, path, handler)
 }
 
 // ANY is a shortcut for group.Handle(router.MethodWild, path, handler)
 //
 // WARNING: Use only for routes where the request method is not important
-func (g *Group) ANY(path string, handler view.MethodViewer) {
-	g.router.ANY(g.prefix+path, handler)
+func (g *Group) ANY(path string, handler view.MethodViewer) *Route {
+	return g.Handle(MethodWild, path, handler)
 }
 
 // ServeFiles serves files from the given file system root.
@@ -60,7 +67,8 @@ func (g *Group) ANY(path string, handler view.MethodViewer) {
 // "/etc/passwd" would be served.
 // Internally a fasthttp.FSHandler is used, therefore http.NotFound is used instead
 // Use:
-//     router.ServeFiles("/src/{filepath:*}", "./")
+//
+//	router.ServeFiles("/src/{filepath:*}", "./")
 func (g *Group) ServeFiles(path string, rootPath string) {
 	g.router.ServeFiles(g.prefix+path, rootPath)
 }
@@ -73,7 +81,8 @@ func (g *Group) ServeFiles(path string, rootPath string) {
 // Internally a fasthttp.FSHandler is used, therefore http.NotFound is used instead
 // of the Router's NotFound handler.
 // Use:
-//     router.ServeFilesCustom("/src/{filepath:*}", *customFS)
+//
+//	router.ServeFilesCustom("/src/{filepath:*}", *customFS)
 func (g *Group) ServeFilesCustom(path string, fs *fasthttp.FS) {
 	g.router.ServeFilesCustom(g.prefix+path, fs)
 }
@@ -86,6 +95,88 @@ func (g *Group) ServeFilesCustom(path string, fs *fasthttp.FS) {
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
-func (g *Group) Handle(method, path string, handler view.MethodViewer) {
-	g.router.Handle(method, g.prefix+path, handler)
+func (g *Group) Handle(method, path string, handler view.MethodViewer) *Route {
+	if len(g.matchers) == 0 && g.version == "" {
+		return g.router.Handle(method, g.prefix+path, handler)
+	}
+
+	root := g.router.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	route := g.router.handle(&Route{
+		method:   method,
+		path:     g.prefix + path,
+		handler:  handler,
+		matchers: append([]Matcher(nil), g.matchers...),
+		version:  g.version,
+		base:     g.base + path,
+	})
+	if g.unprefixed != nil {
+		// the version is served without the version prefix as well
+		g.router.handle(&Route{
+			method:   method,
+			path:     g.base + path,
+			handler:  handler,
+			matchers: append(append([]Matcher(nil), g.matchers...), g.unprefixed),
+			version:  g.version,
+			base:     g.base + path,
+		})
+	}
+
+	return route
+}
+
+// Remove removes the route with the given method and path, see Router.Remove.
+// The routes of versions are removed from the path without the version prefix as well.
+func (g *Group) Remove(method, path string) bool {
+	removed := g.router.Remove(method, g.prefix+path)
+	if g.unprefixed == nil {
+		return removed
+	}
+
+	root := g.router.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	return g.router.remove(method, g.base+path, func(rt *Route) bool {
+		return rt.version == g.version
+	}) || removed
+}
+
+// Replace replaces the handler of the route with the given method and path, see Router.Replace.
+// The routes of versions are replaced on the path without the version prefix as well.
+func (g *Group) Replace(method, path string, handler view.MethodViewer) *Route {
+	if g.unprefixed == nil {
+		return g.router.Replace(method, g.prefix+path, handler)
+	}
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	root := g.router.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	sameVersion := func(rt *Route) bool {
+		return rt.version == g.version
+	}
+	route := g.router.replace(&Route{
+		method:   method,
+		path:     g.prefix + path,
+		handler:  handler,
+		matchers: append([]Matcher(nil), g.matchers...),
+		version:  g.version,
+		base:     g.base + path,
+	}, sameVersion)
+	g.router.replace(&Route{
+		method:   method,
+		path:     g.base + path,
+		handler:  handler,
+		matchers: append(append([]Matcher(nil), g.matchers...), g.unprefixed),
+		version:  g.version,
+		base:     g.base + path,
+	}, sameVersion)
+
+	return route
 }
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/savsgio/gotils"
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// virtualHost is a sub-router serving requests of a host pattern
+type virtualHost struct {
+	pattern string
+	router  *Router
+
+	// nil for exact host names
+	regex *regexp.Regexp
+	keys  []string
+	index []int // submatch index of each key
+}
+
+// Host returns a group whose routes are only served for requests with the given host.
+// The pattern is matched case-insensitively and may contain named labels captured as
+// path parameters, e.g. "{tenant}.example.com" stores "acme" in ctx.UserValue("tenant")
+// for host "acme.example.com". A label may be constrained by a regex: "{tenant:[a-z]+}.example.com",
+// the regex may match dots, e.g. "{v:[0-9]+\.[0-9]+}.example.com" stores "1.2" for host
+// "1.2.example.com". "*" matches any single label without capturing it.
+//
+// Exact host names have priority over patterns, patterns are tried in registration order.
+// Requests whose host doesn't match any registered host are served by the router's own routes.
+// Hosts must be registered before requests are served, their routes could be changed anytime.
+func (r *Router) Host(pattern string) *Group {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	if !strings.ContainsAny(pattern, "{*") {
+		pattern = strings.ToLower(pattern)
+	}
+	for _, vh := range r.hosts {
+		if vh.pattern == pattern {
+			return &Group{router: vh.router}
+		}
+	}
+
+	vh := &virtualHost{
+		pattern: pattern,
+		router:  New(),
+	}
+	vh.router.parent = r
+	vh.router.host = pattern
+	vh.router.SaveMatchedRoutePath = r.SaveMatchedRoutePath
+	vh.router.setMutable(r.treeMutable)
+	vh.regex, vh.keys, vh.index = compileHostPattern(pattern)
+	root.generation++
+
+	if vh.regex == nil {
+		// exact hosts go first
+		i := 0
+		for i < len(r.hosts) && r.hosts[i].regex == nil {
+			i++
+		}
+		r.hosts = append(r.hosts, nil)
+		copy(r.hosts[i+1:], r.hosts[i:])
+		r.hosts[i] = vh
+	} else {
+		r.hosts = append(r.hosts, vh)
+	}
+
+	return &Group{router: vh.router}
+}
+
+// SetNotFound sets the NotFound handler of the host the group belongs to.
+// For groups not created by Router.Host it sets the router's NotFound handler.
+func (g *Group) SetNotFound(handler fasthttp.RequestHandler) {
+	g.router.NotFound = handler
+}
+
+// compileHostPattern returns nil regex if the pattern is an exact host name. Label regexes
+// may contain dots and groups, the submatch of each key is found by its index.
+func compileHostPattern(pattern string) (*regexp.Regexp, []string, []int) {
+	if !strings.ContainsAny(pattern, "{*") {
+		return nil, nil, nil
+	}
+
+	var keys []string
+	expr := strings.Builder{}
+	// hosts are lower case, regexes of labels are kept as they are
+	expr.WriteString("(?i)^")
+
+	for i, label := range HostLabels(pattern) {
+		if i > 0 {
+			expr.WriteString(`\.`)
+		}
+
+		switch {
+		case label == "*":
+			expr.WriteString(`[^.]+`)
+		case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}"):
+			name, re := label[1:len(label)-1], `[^.]+`
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				name, re = name[:i], name[i+1:]
+			}
+			if len(name) == 0 {
+				panic("host labels must be named with a non-empty name in host '" + pattern + "'")
+			}
+			if _, err := regexp.Compile(re); err != nil {
+				panic("invalid regex of host label '" + name + "' in host '" + pattern + "': " + err.Error())
+			}
+			expr.WriteString("(?P<" + hostGroup(len(keys)) + ">(?:" + re + "))")
+			keys = append(keys, name)
+		case strings.ContainsAny(label, "{}*"):
+			panic("a host parameter must be a whole label in host '" + pattern + "'")
+		default:
+			expr.WriteString(regexp.QuoteMeta(label))
+		}
+	}
+	expr.WriteString("$")
+
+	regex := regexp.MustCompile(expr.String())
+	index := make([]int, len(keys))
+	for i, name := range regex.SubexpNames() {
+		for k := range keys {
+			if name == hostGroup(k) {
+				index[k] = i
+			}
+		}
+	}
+
+	return regex, keys, index
+}
+
+// hostGroup is the name of the regex group of the k-th key of a host pattern
+func hostGroup(k int) string {
+	return "host" + strconv.Itoa(k)
+}
+
+// HostLabels splits a host pattern into its labels, dots in the regexes of labels don't
+// separate labels, e.g. "{v:[0-9]+\.[0-9]+}.example.com" has three labels
+func HostLabels(pattern string) []string {
+	var labels []string
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case '.':
+			if depth == 0 {
+				labels = append(labels, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(labels, pattern[start:])
+}
+
+// matchHost returns the router serving the request host, nil if no host matches.
+// Captured labels are appended to params.
+func (r *Router) matchHost(ctx *fasthttp.RequestCtx, params *view.Params) *Router {
+	host := strings.ToLower(gotils.B2S(ctx.Host()))
+
+	if r.HostIgnorePort {
+		if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+			host = host[:i]
+		}
+	}
+
+	for _, vh := range r.hosts {
+		if vh.regex == nil {
+			if vh.pattern == host {
+				return vh.router
+			}
+			continue
+		}
+
+		values := vh.regex.FindStringSubmatch(host)
+		if values == nil {
+			continue
+		}
+
+		for i, key := range vh.keys {
+			*params = append(*params, view.Param{Key: key, Value: values[vh.index[i]]})
+		}
+
+		return vh.router
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestHost(t *testing.T) {
+	r := New()
+	r.HostIgnorePort = true
+	r.HandleFunc(fasthttp.MethodGet, "/", text("default"))
+
+	hosts := []string{
+		"api.example.com",
+		"{tenant}.example.com",
+		"{v:[0-9]+\\.[0-9]+}.versions.example.com",
+		"{env:(dev|prod)}.{region}.example.org",
+		"{code:[a-z]{2}}.{Name:[A-Z]+}.example.net",
+		"*.static.example.com",
+	}
+	for _, host := range hosts {
+		host := host
+		r.Host(host).HandleFunc(fasthttp.MethodGet, "/", func(ctx *fasthttp.RequestCtx) {
+			ctx.SetBodyString(host)
+		})
+	}
+
+	tests := []struct {
+		host   string
+		route  string // host pattern of the route, "default" for the routes of the router
+		params map[string]string
+	}{
+		{"api.example.com", "api.example.com", nil},
+		{"API.Example.com:8080", "api.example.com", nil},
+		{"acme.example.com", "{tenant}.example.com", map[string]string{"tenant": "acme"}},
+		{"a.b.example.com", "default", nil},
+		{"1.12.versions.example.com", "{v:[0-9]+\\.[0-9]+}.versions.example.com", map[string]string{"v": "1.12"}},
+		{"1.x.versions.example.com", "default", nil},
+		{"prod.eu.example.org", "{env:(dev|prod)}.{region}.example.org", map[string]string{"env": "prod", "region": "eu"}},
+		{"test.eu.example.org", "default", nil},
+		{"de.shop.example.net", "{code:[a-z]{2}}.{Name:[A-Z]+}.example.net", map[string]string{"code": "de", "Name": "shop"}},
+		{"cdn.static.example.com", "*.static.example.com", nil},
+		{"example.com", "default", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			params := map[string]string{}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/")
+			ctx.Request.Header.SetHost(tt.host)
+			r.Handler(ctx)
+			ctx.VisitUserValues(func(key []byte, value interface{}) {
+				if s, ok := value.(string); ok {
+					params[string(key)] = s
+				}
+			})
+
+			if route := string(ctx.Response.Body()); route != tt.route {
+				t.Errorf("route was %q; want %q", route, tt.route)
+			}
+			if len(tt.params) > 0 && !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params were %v; want %v", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestHostPanics(t *testing.T) {
+	tests := []string{
+		"{tenant.example.com",
+		"{}.example.com",
+		"{:[a-z]+}.example.com",
+		"api-{tenant}.example.com",
+		"{tenant:[a-z}.example.com",
+	}
+
+	for _, host := range tests {
+		t.Run(host, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("host %s didn't panic", host)
+				}
+			}()
+			New().Host(host)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Matcher is a condition a request must meet besides method and path for a route to match it.
+// Matchers are evaluated after the path is found, see Route.Match.
+type Matcher interface {
+	Match(ctx *fasthttp.RequestCtx) bool
+
+	// String describes the matcher in the route table, e.g. "header(Accept=text/html)"
+	String() string
+}
+
+type matcherFunc struct {
+	desc  string
+	match func(ctx *fasthttp.RequestCtx) bool
+}
+
+func (m *matcherFunc) Match(ctx *fasthttp.RequestCtx) bool {
+	return m.match(ctx)
+}
+
+func (m *matcherFunc) String() string {
+	return m.desc
+}
+
+// MatchFunc returns a matcher calling the predicate, the name describes it in the route table
+func MatchFunc(name string, fn func(ctx *fasthttp.RequestCtx) bool) Matcher {
+	if fn == nil {
+		panic("matcher '" + name + "' has no function")
+	}
+
+	return &matcherFunc{desc: name, match: fn}
+}
+
+// Header returns a matcher of requests with the header set to value
+func Header(name, value string) Matcher {
+	v := []byte(value)
+
+	return &matcherFunc{
+		desc: "header(" + name + "=" + value + ")",
+		match: func(ctx *fasthttp.RequestCtx) bool {
+			return bytes.Equal(ctx.Request.Header.Peek(name), v)
+		},
+	}
+}
+
+// HeaderRegex returns a matcher of requests with the header matching the regex pattern,
+// the pattern is not anchored. It panics if the pattern can't be compiled.
+func HeaderRegex(name, pattern string) Matcher {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("invalid regex of header matcher '%s': %s", name, err))
+	}
+
+	return &matcherFunc{
+		desc: "header(" + name + "~" + pattern + ")",
+		match: func(ctx *fasthttp.RequestCtx) bool {
+			return re.Match(ctx.Request.Header.Peek(name))
+		},
+	}
+}
+
+// Query returns a matcher of requests with the query argument, its value may be empty
+func Query(name string) Matcher {
+	return &matcherFunc{
+		desc: "query(" + name + ")",
+		match: func(ctx *fasthttp.RequestCtx) bool {
+			return ctx.QueryArgs().Has(name)
+		},
+	}
+}
+
+// ContentType returns a matcher of requests with one of the media types, e.g. "application/json".
+// Media types are compared case-insensitively, their parameters are ignored.
+func ContentType(types ...string) Matcher {
+	if len(types) == 0 {
+		panic("content type matcher needs at least one media type")
+	}
+
+	return &matcherFunc{
+		desc: "content-type(" + strings.Join(types, "|") + ")",
+		match: func(ctx *fasthttp.RequestCtx) bool {
+			mt, _, err := mime.ParseMediaType(string(ctx.Request.Header.ContentType()))
+			if err != nil {
+				return false
+			}
+			for _, t := range types {
+				if strings.EqualFold(mt, t) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Scheme returns a matcher of requests with the scheme, "http" or "https"
+func Scheme(scheme string) Matcher {
+	return &matcherFunc{
+		desc: "scheme(" + scheme + ")",
+		match: func(ctx *fasthttp.RequestCtx) bool {
+			return strings.EqualFold(string(ctx.URI().Scheme()), scheme)
+		},
+	}
+}
+
+// Match returns a group whose routes only match requests all of the matchers match. The
+// matchers are set when the routes are registered, so they could be registered before or after
+// the route without matchers of the same path:
+//     r.POST("/upload", &FormUpload{})
+//     r.Match(router.ContentType("application/json")).POST("/upload", &JSONUpload{})
+// Routes of the same path are tried in order of precedence, routes with more matchers
+// first, then in order of registration. The route without matchers is tried last,
+// the request is not found if none matches.
+func (r *Router) Match(ms ...Matcher) *Group {
+	return (&Group{router: r}).Match(ms...)
+}
+
+// Match returns a group whose routes only match requests all of the matchers match,
+// see Router.Match
+func (g *Group) Match(ms ...Matcher) *Group {
+	if len(ms) == 0 {
+		panic("match needs at least one matcher")
+	}
+	for _, m := range ms {
+		if m == nil {
+			panic("matcher must not be nil")
+		}
+	}
+
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix,
+		matchers:   append(append([]Matcher(nil), g.matchers...), ms...),
+		version:    g.version,
+		base:       g.base,
+		unprefixed: g.unprefixed,
+	}
+}
+
+// Match adds matchers to the registered route, the route only matches requests all of them
+// match:
+//     r.GET("/feed", &Atom{}).Match(router.Query("atom"), router.Scheme("https"))
+// The route is registered without matchers first, it conflicts with the route without
+// matchers of the same path unless the router is Mutable. Use Router.Match to register
+// routes with matchers next to such a route.
+func (rt *Route) Match(ms ...Matcher) *Route {
+	root := rt.router.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	for _, m := range ms {
+		if m == nil {
+			panic("matcher must not be nil")
+		}
+	}
+	rt.matchers = append(rt.matchers, ms...)
+
+	r := rt.router
+	for _, other := range r.routes {
+		if other == rt {
+			t := r.writable()
+			r.rebuild(t, rt.method)
+			r.store(t, rt.method)
+			break
+		}
+	}
+
+	return rt
+}
+
+// match reports whether all matchers of the route match the request
+func (m *matchedRoute) match(ctx *fasthttp.RequestCtx) bool {
+	for _, matcher := range m.matchers {
+		if !matcher.Match(ctx) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matcherNames returns the descriptions of the matchers
+func matcherNames(ms []Matcher) []string {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(ms))
+	for i, m := range ms {
+		names[i] = m.String()
+	}
+
+	return names
+}
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// serveHeaders serves a request of the method and uri with the headers given as name, value pairs
+func serveHeaders(r *Router, method, uri string, headers ...string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	for i := 0; i+1 < len(headers); i += 2 {
+		ctx.Request.Header.Set(headers[i], headers[i+1])
+	}
+	r.Handler(ctx)
+	return ctx
+}
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher Matcher
+		uri     string
+		headers []string
+		match   bool
+	}{
+		{"header", Header("X-Version", "2"), "/", []string{"X-Version", "2"}, true},
+		{"other header value", Header("X-Version", "2"), "/", []string{"X-Version", "3"}, false},
+		{"missing header", Header("X-Version", "2"), "/", nil, false},
+		{"header regex", HeaderRegex("User-Agent", "(?i)curl"), "/", []string{"User-Agent", "curl/8.0"}, true},
+		{"header regex mismatch", HeaderRegex("User-Agent", "(?i)curl"), "/", []string{"User-Agent", "Firefox"}, false},
+		{"query", Query("atom"), "/?atom", nil, true},
+		{"missing query", Query("atom"), "/?rss", nil, false},
+		{"content type", ContentType("application/json"), "/", []string{"Content-Type", "Application/JSON; charset=utf-8"}, true},
+		{"other content type", ContentType("application/json"), "/", []string{"Content-Type", "text/plain"}, false},
+		{"invalid content type", ContentType("application/json"), "/", []string{"Content-Type", ";"}, false},
+		{"scheme", Scheme("https"), "https://example.com/", nil, true},
+		{"other scheme", Scheme("https"), "http://example.com/", nil, false},
+		{"func", MatchFunc("even", func(ctx *fasthttp.RequestCtx) bool { return ctx.QueryArgs().GetUintOrZero("n")%2 == 0 }), "/?n=4", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.Match(tt.matcher).HandleFunc(fasthttp.MethodGet, "/", text("matched"))
+
+			ctx := serveHeaders(r, fasthttp.MethodGet, tt.uri, tt.headers...)
+			if matched := string(ctx.Response.Body()) == "matched"; matched != tt.match {
+				t.Errorf("%s matched was %t; want %t", tt.matcher, matched, tt.match)
+			}
+		})
+	}
+}
+
+func TestMatcherRoutes(t *testing.T) {
+	json := ContentType("application/json")
+	v2 := Header("X-Version", "2")
+
+	tests := []struct {
+		name     string
+		register func(r *Router)
+		uri      string
+		headers  []string
+		body     string // "" if the request is not found
+	}{
+		{
+			name: "fallback registered first",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodPost, "/upload", text("form"))
+				r.Match(json).HandleFunc(fasthttp.MethodPost, "/upload", text("json"))
+			},
+			headers: []string{"Content-Type", "application/json"},
+			body:    "json",
+		},
+		{
+			name: "fallback of a request not matching",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodPost, "/upload", text("form"))
+				r.Match(json).HandleFunc(fasthttp.MethodPost, "/upload", text("json"))
+			},
+			headers: []string{"Content-Type", "multipart/form-data"},
+			body:    "form",
+		},
+		{
+			name: "route matchers registered first",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodPost, "/upload", text("json")).Match(json)
+				r.HandleFunc(fasthttp.MethodPost, "/upload", text("form"))
+			},
+			headers: []string{"Content-Type", "application/json"},
+			body:    "json",
+		},
+		{
+			name: "more matchers first",
+			register: func(r *Router) {
+				r.Match(json).HandleFunc(fasthttp.MethodPost, "/upload", text("json"))
+				r.Match(json, v2).HandleFunc(fasthttp.MethodPost, "/upload", text("json v2"))
+			},
+			headers: []string{"Content-Type", "application/json", "X-Version", "2"},
+			body:    "json v2",
+		},
+		{
+			name: "group matchers",
+			register: func(r *Router) {
+				r.Group("/api").Match(json).Match(v2).HandleFunc(fasthttp.MethodPost, "/upload", text("json v2"))
+			},
+			uri:     "/api/upload",
+			headers: []string{"Content-Type", "application/json", "X-Version", "2"},
+			body:    "json v2",
+		},
+		{
+			name: "group matchers not matching",
+			register: func(r *Router) {
+				r.Group("/api").Match(json).Match(v2).HandleFunc(fasthttp.MethodPost, "/upload", text("json v2"))
+			},
+			uri:     "/api/upload",
+			headers: []string{"Content-Type", "application/json"},
+			body:    "",
+		},
+		{
+			name: "no fallback",
+			register: func(r *Router) {
+				r.Match(json).HandleFunc(fasthttp.MethodPost, "/upload", text("json"))
+			},
+			body: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			tt.register(r)
+
+			uri := tt.uri
+			if uri == "" {
+				uri = "/upload"
+			}
+			ctx := serveHeaders(r, fasthttp.MethodPost, uri, tt.headers...)
+			body := string(ctx.Response.Body())
+			if ctx.Response.StatusCode() == fasthttp.StatusNotFound {
+				body = ""
+			}
+			if body != tt.body {
+				t.Errorf("body was %q; want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMatcherPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Router)
+	}{
+		{"no matchers", func(r *Router) { r.Match() }},
+		{"nil matcher", func(r *Router) { r.Match(nil) }},
+		{"nil route matcher", func(r *Router) { r.HandleFunc(fasthttp.MethodGet, "/", text("")).Match(nil) }},
+		{"nil func", func(r *Router) { MatchFunc("nil", nil) }},
+		{"invalid header regex", func(r *Router) { HeaderRegex("Accept", "(") }},
+		{"no content types", func(r *Router) { ContentType() }},
+		{
+			// the route is registered without matchers before they are added
+			name: "route matchers after the fallback",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodGet, "/", text("fallback"))
+				r.HandleFunc(fasthttp.MethodGet, "/", text("matched")).Match(Query("q"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registering didn't panic")
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
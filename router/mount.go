@@ -0,0 +1,130 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"github.com/xxxmailk/cera/view"
+)
+
+// HandlerView is the view of routes registered with HandleFunc and HandleHTTP.
+// The router calls the handler for any method the route is registered for,
+// the view methods are not used.
+type HandlerView struct {
+	handler fasthttp.RequestHandler
+	view.View
+}
+
+// DeepCopy returns the view itself, it has no request state
+func (h *HandlerView) DeepCopy() interface{} {
+	return h
+}
+
+// ServeFastHTTP calls the handler of the route
+func (h *HandlerView) ServeFastHTTP(ctx *fasthttp.RequestCtx) {
+	h.handler(ctx)
+}
+
+// HandleFunc registers a fasthttp.RequestHandler with the given path and method,
+// MethodWild registers it for all methods. Path parameters are available as ctx.UserValue.
+func (r *Router) HandleFunc(method, path string, handler fasthttp.RequestHandler) *Route {
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	return r.Handle(method, path, &HandlerView{handler: handler})
+}
+
+// HandleHTTP registers a net/http handler with the given path and method,
+// MethodWild registers it for all methods. Path parameters are available as
+// request.Context().Value(name).
+//
+// The request and the response are converted by fasthttpadaptor, see its performance notes.
+func (r *Router) HandleHTTP(method, path string, handler http.Handler) *Route {
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	return r.HandleFunc(method, path, fasthttpadaptor.NewFastHTTPHandler(handler))
+}
+
+// Mount registers the routes of the independently built router sub with the prefix,
+// e.g. the route "/users/{id}" of sub mounted at "/api" is served at "/api/users/{id}".
+// The routes keep their names and the hosts of sub are mounted to the same hosts of the
+// router. The settings and NotFound handlers of sub are not used.
+//
+// The routes are copied, routes registered to sub after mounting it are not served.
+func (r *Router) Mount(prefix string, sub *Router) {
+	switch {
+	case sub == nil:
+		panic("sub router must not be nil")
+	case sub.root() == r.root():
+		panic("a router can't be mounted on itself")
+	case prefix != "" && (prefix[0] != '/' || strings.HasSuffix(prefix, "/")):
+		panic("prefix must begin and must not end with '/' in prefix '" + prefix + "'")
+	case len(sub.hosts) > 0 && r.parent != nil:
+		panic("a router with hosts can't be mounted on a host")
+	}
+
+	subRoot := sub.root()
+	subRoot.mu.RLock()
+	routes := append([]*Route(nil), sub.routes...)
+	hosts := append([]*virtualHost(nil), sub.hosts...)
+	subRoot.mu.RUnlock()
+
+	for _, rt := range routes {
+		route := r.mount(prefix, rt)
+		if rt.name != "" {
+			route.Name(rt.name)
+		}
+	}
+
+	for _, vh := range hosts {
+		r.Host(vh.pattern).router.Mount(prefix, vh.router)
+	}
+}
+
+// mount registers a copy of the route of another router with the prefix
+func (r *Router) mount(prefix string, rt *Route) *Route {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	route := &Route{
+		method:   rt.method,
+		path:     prefix + rt.path,
+		handler:  rt.handler,
+		matchers: rt.matchers,
+		version:  rt.version,
+	}
+	if rt.version != "" {
+		route.base = prefix + rt.base
+	}
+
+	return r.handle(route)
+}
+
+// HandleFunc registers a fasthttp.RequestHandler, see Router.HandleFunc
+func (g *Group) HandleFunc(method, path string, handler fasthttp.RequestHandler) *Route {
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	return g.Handle(method, path, &HandlerView{handler: handler})
+}
+
+// HandleHTTP registers a net/http handler, see Router.HandleHTTP
+func (g *Group) HandleHTTP(method, path string, handler http.Handler) *Route {
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	return g.HandleFunc(method, path, fasthttpadaptor.NewFastHTTPHandler(handler))
+}
+
+// Mount registers the routes of sub with the prefix of the group, see Router.Mount
+func (g *Group) Mount(prefix string, sub *Router) {
+	g.router.Mount(g.prefix+prefix, sub)
+}
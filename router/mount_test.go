@@ -0,0 +1,145 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestHandleFunc(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/users/{name}", func(ctx *fasthttp.RequestCtx) {
+		fmt.Fprintf(ctx, "user %s", ctx.UserValue("name"))
+	})
+	r.HandleHTTP(MethodWild, "/legacy/{path:*}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s", req.Method, req.Context().Value("path"))
+	}))
+	r.Group("/api").HandleHTTP(fasthttp.MethodPost, "/echo", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{fasthttp.MethodGet, "/users/alice", fasthttp.StatusOK, "user alice"},
+		{fasthttp.MethodGet, "/legacy/a/b", fasthttp.StatusOK, "GET a/b"},
+		{fasthttp.MethodDelete, "/legacy/a", fasthttp.StatusOK, "DELETE a"},
+		{fasthttp.MethodPost, "/api/echo", fasthttp.StatusCreated, ""},
+		{fasthttp.MethodPost, "/users/alice", fasthttp.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI(tt.path)
+			r.Handler(ctx)
+
+			if status := ctx.Response.StatusCode(); status != tt.status {
+				t.Errorf("status was %d; want %d", status, tt.status)
+			}
+			if body := string(ctx.Response.Body()); tt.body != "" && body != tt.body {
+				t.Errorf("body was %q; want %q", body, tt.body)
+			}
+		})
+	}
+
+	if allow := string(serveMethod(r, fasthttp.MethodPost, "/users/alice").Response.Header.Peek("Allow")); allow != "GET, OPTIONS" {
+		t.Errorf("allowed methods were %q; want %q", allow, "GET, OPTIONS")
+	}
+}
+
+// serveMethod serves a request of the method and path by r
+func serveMethod(r *Router, method, path string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	r.Handler(ctx)
+	return ctx
+}
+
+func TestMount(t *testing.T) {
+	users := New()
+	users.HandleFunc(fasthttp.MethodGet, "/users/{id}", text("user")).Name("user")
+	users.Host("admin.example.com").HandleFunc(fasthttp.MethodGet, "/users", text("admin users"))
+
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/", text("home"))
+	r.Mount("/api", users)
+	status := New()
+	status.HandleFunc(fasthttp.MethodGet, "/status", text("status"))
+	r.Group("/v2").Mount("/api", status)
+	users.HandleFunc(fasthttp.MethodGet, "/late", text("late"))
+
+	tests := []struct {
+		host string
+		path string
+		body string // expected body, empty if the path isn't found
+	}{
+		{"", "/", "home"},
+		{"", "/api/users/1", "user"},
+		{"", "/v2/api/status", "status"},
+		{"admin.example.com", "/api/users", "admin users"},
+		{"", "/api/users", ""},
+		{"", "/api/late", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI(tt.path)
+			ctx.Request.Header.SetHost(tt.host)
+			r.Handler(ctx)
+
+			if tt.body == "" {
+				if status := ctx.Response.StatusCode(); status != fasthttp.StatusNotFound {
+					t.Errorf("status was %d; want %d", status, fasthttp.StatusNotFound)
+				}
+				return
+			}
+			if body := string(ctx.Response.Body()); body != tt.body {
+				t.Errorf("body was %q; want %q", body, tt.body)
+			}
+		})
+	}
+
+	if u, err := r.URL("user", "id", 1); err != nil || u != "/api/users/1" {
+		t.Errorf("url of the mounted route was %q, %v; want %q", u, err, "/api/users/1")
+	}
+}
+
+func TestMountPanics(t *testing.T) {
+	withHosts := New()
+	withHosts.Host("api.example.com").HandleFunc(fasthttp.MethodGet, "/", text("api"))
+
+	tests := []struct {
+		name  string
+		mount func(r *Router)
+	}{
+		{"nil router", func(r *Router) { r.Mount("/api", nil) }},
+		{"itself", func(r *Router) { r.Mount("/api", r) }},
+		{"own host", func(r *Router) { r.Host("example.com").router.Mount("/api", r) }},
+		{"relative prefix", func(r *Router) { r.Mount("api", New()) }},
+		{"trailing slash", func(r *Router) { r.Mount("/api/", New()) }},
+		{"hosts on a host", func(r *Router) { r.Host("example.com").router.Mount("/api", withHosts) }},
+		{"nil handler", func(r *Router) { r.HandleFunc(fasthttp.MethodGet, "/", nil) }},
+		{"nil http handler", func(r *Router) { r.HandleHTTP(fasthttp.MethodGet, "/", nil) }},
+		{"nil group handler", func(r *Router) { r.Group("/api").HandleFunc(fasthttp.MethodGet, "/", nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Errorf("%s didn't panic", tt.name)
+				}
+			}()
+			tt.mount(New())
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package router
 
-import "github.com/savsgio/gotils"
+import (
+	"strings"
+
+	"github.com/savsgio/gotils"
+)
 
 // cleanPath removes the '.' if it is the last character of the route
 func cleanPath(path string) string {
@@ -14,53 +18,35 @@ func cleanPath(path string) string {
 }
 
 // getOptionalPaths returns all possible paths when the original path
-// has optional arguments
+// has optional arguments. Regexes of parameters may contain '?'.
 func getOptionalPaths(path string) []string {
 	paths := make([]string, 0)
 
-	start := 0
-walk:
-	for {
-		if start >= len(path) {
-			return paths
-		}
-
-		c := path[start]
-		start++
+	parts, err := parseURLPattern(path)
+	if err != nil {
+		return paths
+	}
 
-		if c != '{' {
+	optional := false
+	for i, p := range parts {
+		if !p.optional {
 			continue
 		}
+		optional = true
 
-		newPath := ""
-		questionMarkIndex := -1
-
-		for end, c := range []byte(path[start:]) {
-			switch c {
-			case '}':
-				if questionMarkIndex == -1 {
-					continue walk
-				}
-
-				end++
-				newPath += path[questionMarkIndex+1 : start+end]
-
-				path = path[:questionMarkIndex] + path[questionMarkIndex+1:] // remove '?'
-				paths = append(paths, newPath)
-				start += end - 1
-
-				continue walk
-
-			case '?':
-				questionMarkIndex = start + end
-				newPath += path[:questionMarkIndex]
-
-				// include the path without the wildcard
-				// -2 due to remove the '/' and '{'
-				if !gotils.StringSliceInclude(paths, path[:start-2]) {
-					paths = append(paths, path[:start-2])
-				}
-			}
+		// the path without the parameter and the '/' before it
+		prefix := strings.TrimSuffix(formatPattern(parts[:i], false), "/")
+		if prefix == "" {
+			prefix = "/"
+		}
+		if !gotils.StringSliceInclude(paths, prefix) {
+			paths = append(paths, prefix)
 		}
 	}
+
+	if optional {
+		paths = append(paths, formatPattern(parts, false))
+	}
+
+	return paths
 }
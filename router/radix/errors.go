@@ -11,6 +11,9 @@ const (
 	errWildcardConflict   = "'%s' in new path '%s' conflicts with existing wildcard '%s' in existing prefix '%s'"
 	errWildcardSlash      = "no / before wildcard in path '%s'"
 	errWildcardNotAtEnd   = "wildcard routes are only allowed at the end of the path in path '%s'"
+	errWildcardInSegment  = "wildcard '%s' must be the only parameter of its segment in path '%s'"
+	errParamsNotSeparated = "'%s' must be separated from the next parameter by at least 1 char in path '%s'"
+	errDuplicateParam     = "parameter '%s' is used more than once in path '%s'"
 )
 
 type radixError struct {
@@ -1,7 +1,6 @@
 package radix
 
 import (
-	"github.com/valyala/fasthttp"
 	"github.com/xxxmailk/cera/view"
 	"sort"
 	"strings"
@@ -61,16 +60,43 @@ func (n node) clone() *node {
 	}
 
 	cloneNode.paramRegex = n.paramRegex
+	cloneNode.paramGroups = n.paramGroups
 
 	return cloneNode
 }
 
+// copy returns a deep copy of the node and its children, handlers are shared
+func (n *node) copy() *node {
+	c := *n
+
+	if len(n.children) > 0 {
+		c.children = make([]*node, len(n.children))
+
+		for i, child := range n.children {
+			c.children[i] = child.copy()
+		}
+	}
+
+	if n.wildcard != nil {
+		wildcard := *n.wildcard
+		c.wildcard = &wildcard
+	}
+
+	if len(n.paramKeys) > 0 {
+		c.paramKeys = make([]string, len(n.paramKeys))
+		copy(c.paramKeys, n.paramKeys)
+	}
+
+	return &c
+}
+
 func (n *node) split(i int) {
 	cloneChild := n.clone()
 	cloneChild.nType = static
 	cloneChild.path = cloneChild.path[i:]
 	cloneChild.paramKeys = nil
 	cloneChild.paramRegex = nil
+	cloneChild.paramGroups = nil
 
 	n.path = n.path[:i]
 	n.handler = nil
@@ -79,29 +105,20 @@ func (n *node) split(i int) {
 	n.children = append(n.children[:0], cloneChild)
 }
 
+// findEndIndexAndValues matches the segment with the regex of the node and returns
+// its end and the values of the param keys, -1 if it doesn't match
 func (n *node) findEndIndexAndValues(path string) (int, []string) {
 	index := n.paramRegex.FindStringSubmatchIndex(path)
 	if len(index) == 0 {
 		return -1, nil
 	}
 
-	end := index[1]
-
-	index = index[2:]
-	values := make([]string, len(index)/2)
-
-	i := 0
-	for j := range index {
-		if (j+1)%2 != 0 {
-			continue
-		}
-
-		values[i] = path[index[j-1]:index[j]]
-
-		i++
+	values := make([]string, len(n.paramGroups))
+	for i, g := range n.paramGroups {
+		values[i] = path[index[2*g]:index[2*g+1]]
 	}
 
-	return end, values
+	return index[1], values
 }
 
 func (n *node) setHandler(handler view.MethodViewer, fullPath string) (*node, error) {
@@ -161,6 +178,7 @@ func (n *node) insert(path, fullPath string, handler view.MethodViewer) (*node,
 			child.nType = wp.pType
 			child.paramKeys = wp.keys
 			child.paramRegex = wp.regex
+			child.paramGroups = wp.groups
 		case wildcard:
 			if len(path) == end && n.path[len(n.path)-1] != '/' {
 				return nil, newRadixError(errWildcardSlash, fullPath)
@@ -274,7 +292,9 @@ func (n *node) add(path, fullPath string, handler view.MethodViewer) (*node, err
 	return n.insert(path, fullPath, handler)
 }
 
-func (n *node) getFromChild(path string, ctx *fasthttp.RequestCtx) (view.MethodViewer, bool) {
+// getFromChild returns the handler of the path, values of parameters are appended
+// to params in reverse order, see Tree.Get
+func (n *node) getFromChild(path string, params *view.Params) (view.MethodViewer, bool) {
 	var parent *node
 
 	parentIndex, childIndex := 0, 0
@@ -315,8 +335,8 @@ walk:
 					case child.handler != nil:
 						return child.handler, false
 					case child.wildcard != nil:
-						if ctx != nil {
-							ctx.SetUserValue(child.wildcard.paramKey, path)
+						if params != nil {
+							*params = append(*params, view.Param{Key: child.wildcard.paramKey, Value: path})
 						}
 
 						return child.wildcard.handler, false
@@ -337,14 +357,12 @@ walk:
 				}
 
 				if len(path) > end {
-					h, tsr := child.getFromChild(path[end:], ctx)
+					h, tsr := child.getFromChild(path[end:], params)
 					if tsr {
 						return nil, tsr
 					} else if h != nil {
-						if ctx != nil {
-							for i, key := range child.paramKeys {
-								ctx.SetUserValue(key, values[i])
-							}
+						if params != nil {
+							child.appendParams(params, values)
 						}
 
 						return h, false
@@ -357,10 +375,8 @@ walk:
 					case child.handler == nil:
 						// try another child
 						continue
-					case ctx != nil:
-						for i, key := range child.paramKeys {
-							ctx.SetUserValue(key, values[i])
-						}
+					case params != nil:
+						child.appendParams(params, values)
 					}
 
 					return child.handler, false
@@ -388,8 +404,8 @@ walk:
 		}
 
 		if n.wildcard != nil {
-			if ctx != nil {
-				ctx.SetUserValue(n.wildcard.paramKey, path)
+			if params != nil {
+				*params = append(*params, view.Param{Key: n.wildcard.paramKey, Value: path})
 			}
 
 			return n.wildcard.handler, false
@@ -399,6 +415,13 @@ walk:
 	}
 }
 
+// appendParams appends the values of the parameters of the node in reverse order
+func (n *node) appendParams(params *view.Params, values []string) {
+	for i := len(n.paramKeys) - 1; i >= 0; i-- {
+		*params = append(*params, view.Param{Key: n.paramKeys[i], Value: values[i]})
+	}
+}
+
 func (n *node) find(path string, buf *bytebufferpool.ByteBuffer) (bool, bool) {
 	if len(path) > len(n.path) {
 		if !strings.EqualFold(path[:len(n.path)], n.path) {
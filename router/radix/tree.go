@@ -1,7 +1,6 @@
 package radix
 
 import (
-	"github.com/valyala/fasthttp"
 	"github.com/xxxmailk/cera/view"
 	"strings"
 
@@ -27,6 +26,10 @@ func (t *Tree) Add(path string, handler view.MethodViewer) {
 		panic("nil handler")
 	}
 
+	if err := checkParamNames(path); err != nil {
+		panic(err)
+	}
+
 	fullPath := path
 
 	i := longestCommonPrefix(path, t.root.path)
@@ -61,15 +64,35 @@ func (t *Tree) Add(path string, handler view.MethodViewer) {
 	}
 
 	// Reorder the nodes
+	if !t.Unsorted {
+		t.root.sort()
+	}
+}
+
+// Sort reorders the nodes by priority, it's only needed for trees with Unsorted enabled
+//
+// WARNING: Not concurrency-safe!
+func (t *Tree) Sort() {
 	t.root.sort()
 }
 
+// Clone returns a deep copy of the tree, handlers are shared.
+// Routes could be added to the copy while the tree is being read.
+func (t *Tree) Clone() *Tree {
+	return &Tree{
+		root:     t.root.copy(),
+		Mutable:  t.Mutable,
+		Unsorted: t.Unsorted,
+	}
+}
+
 // Get returns the handle registered with the given path (key). The values of
-// param/wildcard are saved as ctx.UserValue.
+// param/wildcard are appended to params in path order, params may be nil if
+// they are not needed. The slice could be reused for lookups after truncating it.
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
-func (t *Tree) Get(path string, ctx *fasthttp.RequestCtx) (view.MethodViewer, bool) {
+func (t *Tree) Get(path string, params *view.Params) (view.MethodViewer, bool) {
 	if len(path) > len(t.root.path) {
 		if path[:len(t.root.path)] != t.root.path {
 			return nil, false
@@ -77,7 +100,21 @@ func (t *Tree) Get(path string, ctx *fasthttp.RequestCtx) (view.MethodViewer, bo
 
 		path = path[len(t.root.path):]
 
-		return t.root.getFromChild(path, ctx)
+		start := 0
+		if params != nil {
+			start = len(*params)
+		}
+
+		handler, tsr := t.root.getFromChild(path, params)
+		if handler != nil && params != nil {
+			// values are appended while returning from the deepest node
+			ps := (*params)[start:]
+			for i, j := 0, len(ps)-1; i < j; i, j = i+1, j-1 {
+				ps[i], ps[j] = ps[j], ps[i]
+			}
+		}
+
+		return handler, tsr
 
 	} else if path == t.root.path {
 		switch {
@@ -86,8 +123,8 @@ func (t *Tree) Get(path string, ctx *fasthttp.RequestCtx) (view.MethodViewer, bo
 		case t.root.handler != nil:
 			return t.root.handler, false
 		case t.root.wildcard != nil:
-			if ctx != nil {
-				t.root.wildcard.handler.GetCtx().SetUserValue(t.root.wildcard.paramKey, "/")
+			if params != nil {
+				*params = append(*params, view.Param{Key: t.root.wildcard.paramKey, Value: "/"})
 			}
 
 			return t.root.wildcard.handler, false
@@ -0,0 +1,119 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xxxmailk/cera/view"
+)
+
+type testHandler struct {
+	view.View
+	path string
+}
+
+func newTestTree(paths ...string) *Tree {
+	tree := New()
+	for _, path := range paths {
+		tree.Add(path, &testHandler{path: path})
+	}
+	return tree
+}
+
+func TestTreeGet(t *testing.T) {
+	tree := newTestTree(
+		"/files/{name}.{ext}",
+		"/geo/{lat},{lng}",
+		"/versions/{major:\\d+}.{minor:\\d+}",
+		"/codes/{code:(ab|cd)(\\d+)}-{suffix}",
+		"/users/{id}",
+		"/users/me",
+		"/dir/",
+		"/static/{filepath:*}",
+	)
+
+	tests := []struct {
+		path   string
+		route  string // registered path of the found handler, empty if none is found
+		params view.Params
+		tsr    bool
+	}{
+		{"/files/report.pdf", "/files/{name}.{ext}", view.Params{{Key: "name", Value: "report"}, {Key: "ext", Value: "pdf"}}, false},
+		{"/files/a.tar.gz", "/files/{name}.{ext}", view.Params{{Key: "name", Value: "a.tar"}, {Key: "ext", Value: "gz"}}, false},
+		{"/files/report", "", nil, false},
+		{"/geo/52.5,-13.4", "/geo/{lat},{lng}", view.Params{{Key: "lat", Value: "52.5"}, {Key: "lng", Value: "-13.4"}}, false},
+		{"/geo/52.5", "", nil, false},
+		{"/versions/1.12", "/versions/{major:\\d+}.{minor:\\d+}", view.Params{{Key: "major", Value: "1"}, {Key: "minor", Value: "12"}}, false},
+		{"/versions/1.x", "", nil, false},
+		{"/codes/cd42-x", "/codes/{code:(ab|cd)(\\d+)}-{suffix}", view.Params{{Key: "code", Value: "cd42"}, {Key: "suffix", Value: "x"}}, false},
+		{"/codes/ef42-x", "", nil, false},
+		{"/users/me", "/users/me", nil, false},
+		{"/users/42", "/users/{id}", view.Params{{Key: "id", Value: "42"}}, false},
+		{"/users/42/", "", nil, true},
+		{"/dir", "", nil, true},
+		{"/dir/", "/dir/", nil, false},
+		{"/static/css/site.css", "/static/{filepath:*}", view.Params{{Key: "filepath", Value: "css/site.css"}}, false},
+		{"/unknown", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var params view.Params
+			handler, tsr := tree.Get(tt.path, &params)
+
+			route := ""
+			if handler != nil {
+				route = handler.(*testHandler).path
+			}
+			if route != tt.route {
+				t.Errorf("route was %q; want %q", route, tt.route)
+			}
+			if route != "" && !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params were %v; want %v", params, tt.params)
+			}
+			if tsr != tt.tsr {
+				t.Errorf("tsr was %t; want %t", tsr, tt.tsr)
+			}
+		})
+	}
+}
+
+func TestTreeAddPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{"duplicate param in path", []string{"/users/{id}/posts/{id}"}},
+		{"duplicate param in segment", []string{"/files/{name}.{name}"}},
+		{"duplicate regex param", []string{"/v/{n:\\d+}/{n}"}},
+		{"params not separated", []string{"/files/{name}{ext}"}},
+		{"wildcard not at end", []string{"/static/{filepath:*}/more"}},
+		{"handler registered twice", []string{"/users/{id}", "/users/{id}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("adding %v didn't panic", tt.paths)
+				}
+			}()
+			newTestTree(tt.paths...)
+		})
+	}
+}
+
+func TestTreeUnsorted(t *testing.T) {
+	tree := New()
+	tree.Unsorted = true
+	tree.Add("/users/{id}", &testHandler{path: "/users/{id}"})
+	tree.Add("/users/me", &testHandler{path: "/users/me"})
+	tree.Sort()
+
+	for path, want := range map[string]string{"/users/me": "/users/me", "/users/7": "/users/{id}"} {
+		handler, _ := tree.Get(path, nil)
+		if handler == nil || handler.(*testHandler).path != want {
+			t.Errorf("handler of %s was %v; want %s", path, handler, want)
+		}
+	}
+}
@@ -26,6 +26,9 @@ type node struct {
 
 	paramKeys  []string
 	paramRegex *regexp.Regexp
+
+	// submatch index of the value of each param key in paramRegex
+	paramGroups []int
 }
 
 type wildPath struct {
@@ -37,6 +40,10 @@ type wildPath struct {
 
 	pattern string
 	regex   *regexp.Regexp
+
+	// submatch index of each key in regex and the number of groups of the pattern
+	groups  []int
+	nGroups int
 }
 
 // Tree is a routes storage
@@ -45,4 +52,8 @@ type Tree struct {
 
 	// If enabled, the node handler could be updated
 	Mutable bool
+
+	// If enabled, Add doesn't reorder the nodes, e.g. to add many paths at once.
+	// Sort must be called before the tree is read.
+	Unsorted bool
 }
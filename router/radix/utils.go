@@ -70,7 +70,9 @@ func segmentEndIndex(path string, includeTSR bool) int {
 }
 
 // findWildPath search for a wild path segment and check the name for invalid characters.
-// Returns -1 as index, if no param/wildcard was found.
+// Parameters followed by other chars of their segment, e.g. "{name}.{ext}", are matched
+// by a regex of the whole segment.
+// Returns nil, if no param/wildcard was found.
 func findWildPath(path string, fullPath string) *wildPath {
 	// Find start
 	for start, c := range []byte(path) {
@@ -93,15 +95,18 @@ func findWildPath(path string, fullPath string) *wildPath {
 
 				end := start + end + 2
 				wp := &wildPath{
-					path:  path[start:end],
-					keys:  []string{path[start+1 : end-1]},
-					start: start,
-					end:   end,
-					pType: param,
+					path:    path[start:end],
+					keys:    []string{path[start+1 : end-1]},
+					start:   start,
+					end:     end,
+					pType:   param,
+					pattern: "([^/]+)",
+					groups:  []int{1},
+					nGroups: 1,
 				}
 
 				if len(path) > end && path[end] == '{' {
-					panic("the wildcards must be separated by at least 1 char")
+					panic(newRadixError(errParamsNotSeparated, wp.path, fullPath))
 				}
 
 				sn := strings.SplitN(wp.keys[0], ":", 2)
@@ -114,10 +119,9 @@ func findWildPath(path string, fullPath string) *wildPath {
 						wp.pType = wildcard
 					} else {
 						wp.pattern = "(" + pattern + ")"
-						wp.regex = regexp.MustCompile(wp.pattern)
+						wp.nGroups += regexp.MustCompile(pattern).NumSubexp()
+						wp.regex = compileSegment(wp.pattern)
 					}
-				} else if path[len(path)-1] != '/' {
-					wp.pattern = "(.*)"
 				}
 
 				if len(wp.keys[0]) == 0 {
@@ -134,22 +138,34 @@ func findWildPath(path string, fullPath string) *wildPath {
 				}
 
 				if len(path) > 0 {
+					if wp.pType == wildcard {
+						panic(newRadixError(errWildcardNotAtEnd, fullPath))
+					}
+
 					// Rebuild the wildpath with the prefix
 					wp2 := findWildPath(path, fullPath)
 					if wp2 != nil {
+						if wp2.pType == wildcard {
+							panic(newRadixError(errWildcardInSegment, wp2.path, fullPath))
+						}
+
 						prefix := path[:wp2.start]
 
 						wp.end += wp2.end
 						wp.path += prefix + wp2.path
-						wp.pattern += prefix + wp2.pattern
+						wp.pattern += regexp.QuoteMeta(prefix) + wp2.pattern
 						wp.keys = append(wp.keys, wp2.keys...)
+						for _, g := range wp2.groups {
+							wp.groups = append(wp.groups, wp.nGroups+g)
+						}
+						wp.nGroups += wp2.nGroups
 					} else {
 						wp.path += path
-						wp.pattern += path
+						wp.pattern += regexp.QuoteMeta(path)
 						wp.end += len(path)
 					}
 
-					wp.regex = regexp.MustCompile(wp.pattern)
+					wp.regex = compileSegment(wp.pattern)
 				}
 
 				return wp
@@ -169,3 +185,45 @@ func findWildPath(path string, fullPath string) *wildPath {
 
 	return nil
 }
+
+// compileSegment compiles the regex matching a whole path segment
+func compileSegment(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+// checkParamNames returns an error if a parameter name is used more than once in the path
+func checkParamNames(path string) error {
+	names := make(map[string]bool)
+	start, depth := -1, 0
+
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			if start < 0 {
+				start = i
+			} else {
+				depth++
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if start < 0 {
+				continue
+			}
+
+			name := path[start+1 : i]
+			if j := strings.IndexByte(name, ':'); j >= 0 {
+				name = name[:j]
+			}
+			if names[name] {
+				return newRadixError(errDuplicateParam, name, path)
+			}
+			names[name] = true
+			start = -1
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// resourceView is the view of the collection or item routes of a resource,
+// the router calls the actions of the resource view by view.SwitchResource
+type resourceView struct {
+	view.View
+	resource view.MethodViewer
+	item     bool
+	param    string
+}
+
+// DeepCopy returns the view itself, the resource view is copied for each request
+func (rv *resourceView) DeepCopy() interface{} {
+	return rv
+}
+
+func (rv *resourceView) switcher(v view.MethodViewer) error {
+	return view.SwitchResource(v, rv.item, rv.param)
+}
+
+// viewOf returns the view registered for the handler of a route,
+// the resource view of resource routes
+func viewOf(handler view.MethodViewer) view.MethodViewer {
+	if rv, ok := handler.(*resourceView); ok {
+		return rv.resource
+	}
+	return handler
+}
+
+// resourceActions are the methods of the resource actions on the collection and on items
+var resourceActions = []struct {
+	method      string
+	item        bool
+	implemented func(v view.MethodViewer) bool
+}{
+	{fasthttp.MethodGet, false, func(v view.MethodViewer) bool { _, ok := v.(view.Lister); return ok }},
+	{fasthttp.MethodPost, false, func(v view.MethodViewer) bool { _, ok := v.(view.Creator); return ok }},
+	{fasthttp.MethodGet, true, func(v view.MethodViewer) bool { _, ok := v.(view.Retriever); return ok }},
+	{fasthttp.MethodPut, true, func(v view.MethodViewer) bool { _, ok := v.(view.Updater); return ok }},
+	{fasthttp.MethodPatch, true, func(v view.MethodViewer) bool { _, ok := v.(view.PartialUpdater); return ok }},
+	{fasthttp.MethodDelete, true, func(v view.MethodViewer) bool { _, ok := v.(view.Destroyer); return ok }},
+}
+
+// ResourceOptions are the settings of a resource, see Router.Resource
+type ResourceOptions struct {
+	// Param is the path parameter of the item id, view.ResourceIDParam if it's empty.
+	// Resources nested in an item need a param of their own, e.g. "post" for the items
+	// "/users/{id}/posts/{post}" of the resource "/users/{id}/posts".
+	Param string
+}
+
+// Resource registers the actions the REST resource view v implements, see view.ResourceView,
+// for the collection path and its items:
+//     GET    /articles       List
+//     POST   /articles       Create         201 Created with Location /articles/{id}
+//     GET    /articles/{id}  Retrieve
+//     PUT    /articles/{id}  Update
+//     PATCH  /articles/{id}  PartialUpdate
+//     DELETE /articles/{id}  Destroy        204 No Content
+// Methods of actions the view doesn't implement are answered with 405 Method Not Allowed.
+// The registered routes are returned in this order. The item param is set by opts, at most
+// one ResourceOptions could be given:
+//     r.Resource("/users/{id}/posts", &Posts{}, router.ResourceOptions{Param: "post"})
+func (r *Router) Resource(path string, v view.MethodViewer, opts ...ResourceOptions) []*Route {
+	return resource(r.Handle, path, v, opts)
+}
+
+// Resource registers the actions of the REST resource view v, see Router.Resource
+func (g *Group) Resource(path string, v view.MethodViewer, opts ...ResourceOptions) []*Route {
+	return resource(g.Handle, path, v, opts)
+}
+
+func resource(handle func(method, path string, handler view.MethodViewer) *Route, path string,
+	v view.MethodViewer, opts []ResourceOptions) []*Route {
+	switch {
+	case v == nil:
+		panic("resource view must not be nil")
+	case len(opts) > 1:
+		panic("at most one ResourceOptions could be given in path '" + path + "'")
+	}
+
+	param := view.ResourceIDParam
+	if len(opts) == 1 && opts[0].Param != "" {
+		param = opts[0].Param
+	}
+	collection := &resourceView{resource: v, param: param}
+	item := &resourceView{resource: v, item: true, param: param}
+	itemPath := strings.TrimSuffix(path, "/") + "/{" + param + "}"
+
+	var routes []*Route
+	for _, a := range resourceActions {
+		switch {
+		case !a.implemented(v):
+		case a.item:
+			routes = append(routes, handle(a.method, itemPath, item))
+		default:
+			routes = append(routes, handle(a.method, path, collection))
+		}
+	}
+	if len(routes) == 0 {
+		panic("resource view implements no action in path '" + path + "'")
+	}
+
+	return routes
+}
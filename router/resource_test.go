@@ -0,0 +1,97 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+// articles is a resource view listing, retrieving, creating and destroying articles
+type articles struct {
+	view.View
+}
+
+func (a *articles) List()              { a.Ctx.SetBodyString("list") }
+func (a *articles) Retrieve(id string) { a.Ctx.SetBodyString("retrieve " + id) }
+func (a *articles) Create() string     { return "new id" }
+func (a *articles) Destroy(id string)  {}
+func (a *articles) Render()            {}
+
+// comments is a resource view nested in the items of articles
+type comments struct {
+	view.View
+}
+
+func (c *comments) Retrieve(id string) {
+	c.Ctx.SetBodyString("comment " + id + " of " + c.Ctx.UserValue("id").(string))
+}
+func (c *comments) Render() {}
+
+func TestResource(t *testing.T) {
+	r := New()
+	r.Resource("/articles", &articles{})
+	r.Resource("/articles/{id}/comments", &comments{}, ResourceOptions{Param: "comment"})
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		body     string
+		location string
+		allow    string
+	}{
+		{fasthttp.MethodGet, "/articles", fasthttp.StatusOK, "list", "", ""},
+		{fasthttp.MethodPost, "/articles", fasthttp.StatusCreated, "", "/articles/new%20id", ""},
+		{fasthttp.MethodGet, "/articles/7", fasthttp.StatusOK, "retrieve 7", "", ""},
+		{fasthttp.MethodDelete, "/articles/7", fasthttp.StatusNoContent, "", "", ""},
+		{fasthttp.MethodPut, "/articles/7", fasthttp.StatusMethodNotAllowed, "", "", "DELETE, GET, OPTIONS"},
+		{fasthttp.MethodDelete, "/articles", fasthttp.StatusMethodNotAllowed, "", "", "GET, OPTIONS, POST"},
+		{fasthttp.MethodGet, "/articles/7/comments/3", fasthttp.StatusOK, "comment 3 of 7", "", ""},
+		{fasthttp.MethodGet, "/articles/7/comments", fasthttp.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			ctx := serveHeaders(r, tt.method, tt.path)
+
+			if status := ctx.Response.StatusCode(); status != tt.status {
+				t.Errorf("status was %d; want %d", status, tt.status)
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("body was %q; want %q", ctx.Response.Body(), tt.body)
+			}
+			if location := string(ctx.Response.Header.Peek(fasthttp.HeaderLocation)); location != tt.location {
+				t.Errorf("location was %q; want %q", location, tt.location)
+			}
+			if allow := string(ctx.Response.Header.Peek(fasthttp.HeaderAllow)); allow != tt.allow {
+				t.Errorf("allow was %q; want %q", allow, tt.allow)
+			}
+		})
+	}
+}
+
+func TestResourcePanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Router)
+	}{
+		{"nil view", func(r *Router) { r.Resource("/articles", nil) }},
+		{"no action", func(r *Router) { r.Resource("/articles", &HandlerView{}) }},
+		{"nested with the same param", func(r *Router) { r.Resource("/articles/{id}/comments", &comments{}) }},
+		{"several options", func(r *Router) {
+			r.Resource("/articles", &articles{}, ResourceOptions{}, ResourceOptions{Param: "article"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registering didn't panic")
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"github.com/xxxmailk/cera/view"
+)
+
+// Route is a registered route, it's returned by the registering functions
+// so the route could be configured further, e.g.:
+//     r.GET("/articles/{id:[0-9]+}", &Article{}).Name("article")
+type Route struct {
+	router  *Router
+	method  string
+	path    string
+	handler view.MethodViewer
+	name    string
+
+	// parsed path pattern for url generation and introspection
+	urlParts []urlPart
+
+	// matchers which must match the request besides method and path
+	matchers []Matcher
+
+	// the route was registered with Router.SaveMatchedRoutePath enabled
+	savePath bool
+
+	// API version of the route and its path without the version prefix,
+	// both are empty if the route is not versioned
+	version string
+	base    string
+}
+
+// Method returns the method of the route, MethodWild for ANY routes
+func (rt *Route) Method() string {
+	return rt.method
+}
+
+// Path returns the path pattern of the route
+func (rt *Route) Path() string {
+	return rt.path
+}
+
+// GetName returns the name of the route, empty if the route is not named
+func (rt *Route) GetName() string {
+	return rt.name
+}
+
+// Name names the route so its url could be generated with Router.URL.
+// Names are shared by the router and all its hosts, it panics if the name is already used.
+func (rt *Route) Name(name string) *Route {
+	root := rt.router.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	if other, ok := root.names[name]; ok && other != rt {
+		panic("a route named '" + name + "' is already registered for path '" + other.path + "'")
+	}
+	if rt.name != "" {
+		delete(root.names, rt.name)
+	}
+
+	rt.name = name
+	root.names[name] = rt
+
+	return rt
+}
+
+// root returns the router the virtual host router belongs to, or the router itself
+func (r *Router) root() *Router {
+	for r.parent != nil {
+		r = r.parent
+	}
+
+	return r
+}
@@ -1,9 +1,13 @@
 package router
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/xxxmailk/cera/view"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/savsgio/gotils"
 	"github.com/valyala/bytebufferpool"
@@ -15,6 +19,36 @@ import (
 // MethodWild wild HTTP method
 const MethodWild = "*"
 
+// urlFuncSetter is implemented by views embedding view.View
+type urlFuncSetter interface {
+	SetURLFunc(fn view.URLFunc)
+}
+
+// paramsSetter is implemented by views embedding view.View
+type paramsSetter interface {
+	SetParams(ps view.Params)
+}
+
+// sessionStoreSetter is implemented by views embedding view.View
+type sessionStoreSetter interface {
+	SetSessionStore(st *view.SessionStore)
+}
+
+// matchedRoute is stored in the trees instead of the handler of routes registered
+// with SaveMatchedRoutePath, converters or matchers, as they are needed when the route matches
+type matchedRoute struct {
+	view.MethodViewer
+
+	// path pattern of the route, empty if it's not saved
+	path       string
+	converters []paramConverter
+	matchers   []Matcher
+	version    string
+
+	// next route with the same method and path, in order of precedence
+	next *matchedRoute
+}
+
 var (
 	defaultContentType = []byte("text/plain; charset=utf-8")
 	questionMark       = byte('?')
@@ -22,14 +56,28 @@ var (
 	// MatchedRoutePathParam is the param name under which the path of the matched
 	// route is stored, if Router.SaveMatchedRoutePath is set.
 	MatchedRoutePathParam = fmt.Sprintf("__matchedRoutePath::%s__", gotils.RandBytes(make([]byte, 15)))
+
+	// VersionParam is the param name under which the API version of the matched
+	// route is stored, if the route is registered with Router.Version.
+	VersionParam = fmt.Sprintf("__apiVersion::%s__", gotils.RandBytes(make([]byte, 15)))
+
+	// MatchedRouteParam is the param name under which the RouteInfo of the matched
+	// route is stored before the PanicHandler is called for a panic of its view.
+	MatchedRouteParam = fmt.Sprintf("__matchedRoute::%s__", gotils.RandBytes(make([]byte, 15)))
+
+	paramsPool = sync.Pool{
+		New: func() interface{} {
+			ps := make(view.Params, 0, 8)
+			return &ps
+		},
+	}
 )
 
 // New returns a new router.
 // Path auto-correction, including trailing slashes, is enabled by default.
 func New() *Router {
 	return &Router{
-		trees:                  make(map[string]*radix.Tree),
-		registeredPaths:        make(map[string][]string),
+		names:                  make(map[string]*Route),
 		RedirectTrailingSlash:  true,
 		RedirectFixedPath:      true,
 		HandleMethodNotAllowed: true,
@@ -43,80 +91,163 @@ func (r *Router) Group(path string) *Group {
 	return &Group{
 		router: r,
 		prefix: path,
+		base:   path,
 	}
 }
 
-// todo
-func (r *Router) saveMatchedRoutePath(path string, handler view.MethodViewer) view.MethodViewer {
-	//return func(ctx *fasthttp.RequestCtx) {
-	//	ctx.SetUserValue(MatchedRoutePathParam, path)
-	//	handler.SetCtx(ctx)
-	//	handler.Init()
-	//	view.Switcher(handler)
-	//}
-	ctx := handler.GetCtx()
-	ctx.SetUserValue(MatchedRoutePathParam, path)
+// treeValue returns the handler to store in the trees for the routes with the same method
+// and path. Routes with more matchers have precedence, the route without matchers is the last one.
+// Routes registered again on a mutable router replace the former ones.
+func treeValue(routes []*Route) view.MethodViewer {
+	var fallback *Route
+	ordered := make([]*Route, 0, len(routes))
+	for _, rt := range routes {
+		if len(rt.matchers) == 0 {
+			fallback = rt
+		} else {
+			ordered = append(ordered, rt)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return len(ordered[i].matchers) > len(ordered[j].matchers)
+	})
+	if fallback != nil {
+		ordered = append(ordered, fallback)
+	}
+
+	if len(ordered) == 1 && fallback != nil && !fallback.savePath && fallback.version == "" &&
+		len(paramConverters(fallback.urlParts)) == 0 {
+		return fallback.handler
+	}
+
+	var head *matchedRoute
+	for i := len(ordered) - 1; i >= 0; i-- {
+		rt := ordered[i]
+		m := &matchedRoute{
+			MethodViewer: rt.handler,
+			converters:   paramConverters(rt.urlParts),
+			matchers:     rt.matchers,
+			version:      rt.version,
+			next:         head,
+		}
+		if rt.savePath {
+			m.path = rt.path
+		}
+		head = m
+	}
+
+	return head
+}
+
+// unwrap returns the handler of the first route stored in a tree
+func unwrap(handler view.MethodViewer) view.MethodViewer {
+	if m, ok := handler.(*matchedRoute); ok {
+		return m.MethodViewer
+	}
 	return handler
 }
 
-// Mutable allows updating the route handler
+// lookup returns the handler of the path from the tree, path parameters and the
+// matched route path of routes registered with SaveMatchedRoutePath are appended to params.
+// Routes whose parameters can't be converted or whose matchers don't match ctx don't match,
+// matchers are not evaluated if ctx is nil.
+func lookup(tree *radix.Tree, path string, params *view.Params, ctx *fasthttp.RequestCtx) (view.MethodViewer, bool) {
+	start := 0
+	if params != nil {
+		start = len(*params)
+	}
+
+	handler, tsr := tree.Get(path, params)
+	m, ok := handler.(*matchedRoute)
+	if !ok || params == nil {
+		return unwrap(handler), tsr
+	}
+
+	for ctx != nil && m != nil && !m.match(ctx) {
+		m = m.next
+	}
+	if m == nil || !convertParams((*params)[start:], m.converters) {
+		*params = (*params)[:start]
+		return nil, false
+	}
+	if m.path != "" {
+		*params = append(*params, view.Param{Key: MatchedRoutePathParam, Value: m.path})
+	}
+	if m.version != "" {
+		*params = append(*params, view.Param{Key: VersionParam, Value: m.version})
+	}
+
+	return m.MethodViewer, tsr
+}
+
+// Mutable allows updating the route handler by registering the route again
 //
-// It's disabled by default
+// It's disabled by default, use Replace to replace a route in any case
 //
 // WARNING: Use with care. It could generate unexpected behaviours
 func (r *Router) Mutable(v bool) {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	r.setMutable(v)
+}
+
+func (r *Router) setMutable(v bool) {
 	r.treeMutable = v
 
-	for method := range r.trees {
-		r.trees[method].Mutable = v
+	for _, vh := range r.hosts {
+		vh.router.setMutable(v)
 	}
 }
 
-// List returns all registered routes grouped by method
+// List returns all registered routes grouped by method, it freezes the router.
+// The returned map must not be modified.
 func (r *Router) List() map[string][]string {
-	return r.registeredPaths
+	r.root().freeze()
+	return r.load().registeredPaths
 }
 
 // GET is a shortcut for router.Handle(fasthttp.MethodGet, path, handler)
-func (r *Router) GET(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodGet, path, handler)
+func (r *Router) GET(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodGet, path, handler)
 }
 
 // HEAD is a shortcut for router.Handle(fasthttp.MethodHead, path, handler)
-func (r *Router) HEAD(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodHead, path, handler)
+func (r *Router) HEAD(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodHead, path, handler)
 }
 
 // OPTIONS is a shortcut for router.Handle(fasthttp.MethodOptions, path, handler)
-func (r *Router) OPTIONS(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodOptions, path, handler)
+func (r *Router) OPTIONS(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodOptions, path, handler)
 }
 
 // POST is a shortcut for router.Handle(fasthttp.MethodPost, path, handler)
-func (r *Router) POST(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodPost, path, handler)
+func (r *Router) POST(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodPost, path, handler)
 }
 
 // PUT is a shortcut for router.Handle(fasthttp.MethodPut, path, handler)
-func (r *Router) PUT(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodPut, path, handler)
+func (r *Router) PUT(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodPut, path, handler)
 }
 
 // PATCH is a shortcut for router.Handle(fasthttp.MethodPatch, path, handler)
-func (r *Router) PATCH(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodPatch, path, handler)
+func (r *Router) PATCH(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodPatch, path, handler)
 }
 
 // DELETE is a shortcut for router.Handle(fasthttp.MethodDelete, path, handler)
-func (r *Router) DELETE(path string, handler view.MethodViewer) {
-	r.Handle(fasthttp.MethodDelete, path, handler)
+func (r *Router) DELETE(path string, handler view.MethodViewer) *Route {
+	return r.Handle(fasthttp.MethodDelete, path, handler)
 }
 
 // ANY is a shortcut for router.Handle(router.MethodWild, path, handler)
 //
 // WARNING: Use only for routes where the request method is not important
-func (r *Router) ANY(path string, handler view.MethodViewer) {
-	r.Handle(MethodWild, path, handler)
+func (r *Router) ANY(path string, handler view.MethodViewer) *Route {
+	return r.Handle(MethodWild, path, handler)
 }
 
 // ServeFiles serves files from the given file system root.
@@ -136,6 +267,9 @@ func (r *Router) ServeFiles(path string, rootPath string) {
 	})
 }
 
+// FileHandle serves files as view.
+//
+// Deprecated: ServeFilesCustom registers the file handler with HandleFunc.
 type FileHandle struct {
 	fileHandle fasthttp.RequestHandler
 	ctx        fasthttp.RequestCtx
@@ -171,9 +305,7 @@ func (r *Router) ServeFilesCustom(path string, fs *fasthttp.FS) {
 		fs.PathRewrite = fasthttp.NewPathSlashesStripper(stripSlashes)
 	}
 
-	fileHandler := &FileHandle{fileHandle: fs.NewRequestHandler()}
-
-	r.GET(path, fileHandler)
+	r.HandleFunc(fasthttp.MethodGet, path, fs.NewRequestHandler())
 }
 
 // Handle registers a new request handler with the given path and method.
@@ -184,7 +316,21 @@ func (r *Router) ServeFilesCustom(path string, fs *fasthttp.FS) {
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
-func (r *Router) Handle(method, path string, handler view.MethodViewer) {
+//
+// Routes could be registered while requests are served, the routes are changed
+// on a copy of the routing table which then replaces the one in use.
+func (r *Router) Handle(method, path string, handler view.MethodViewer) *Route {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	return r.handle(&Route{method: method, path: path, handler: handler})
+}
+
+// handle registers the route with its method, path, handler and optional matchers and
+// version set, the caller holds the lock of the root router
+func (r *Router) handle(route *Route) *Route {
+	method, path, handler := route.method, route.path, route.handler
 	switch {
 	case len(method) == 0:
 		panic("method must not be empty")
@@ -193,48 +339,99 @@ func (r *Router) Handle(method, path string, handler view.MethodViewer) {
 	case handler == nil:
 		panic("handler must not be nil")
 	}
-	r.registeredPaths[method] = append(r.registeredPaths[method], path)
 
-	tree := r.trees[method]
-	if tree == nil {
-		tree = radix.New()
-		tree.Mutable = r.treeMutable
+	urlParts, err := parseURLPattern(path)
+	if err != nil {
+		panic(err)
+	}
 
-		r.trees[method] = tree
-		r.globalAllowed = r.allowed("*", "")
+	key := routeKey(method, path)
+	same := r.byPath[key]
+	var replaced *Route
+	for _, rt := range same {
+		if len(rt.matchers) == 0 && len(route.matchers) == 0 {
+			if !r.treeMutable {
+				panic("a handler is already registered for path '" + path + "'")
+			}
+			// registered again on a mutable router, the route replaces the former one
+			replaced = rt
+		}
 	}
 
-	if r.SaveMatchedRoutePath {
-		handler = r.saveMatchedRoutePath(path, handler)
+	// routes are registered in place until the router is frozen
+	frozen := r.root().isFrozen()
+	t := r.writable()
+	tree := t.trees[method]
+	switch {
+	case tree == nil:
+		tree = radix.New()
+	case frozen:
+		tree = tree.Clone()
+	}
+	tree.Mutable = r.treeMutable
+	tree.Unsorted = !frozen
+	t.trees[method] = tree
+	switch {
+	case len(same) > 0:
+		// the path is registered already
+	case frozen:
+		t.registeredPaths[method] = append(append([]string(nil), t.registeredPaths[method]...), path)
+	default:
+		t.registeredPaths[method] = append(t.registeredPaths[method], path)
 	}
 
-	optionalPaths := getOptionalPaths(path)
+	route.router = r
+	route.urlParts = urlParts
+	route.savePath = r.SaveMatchedRoutePath
 
-	// if not has optional paths, adds the original
-	if len(optionalPaths) == 0 {
-		tree.Add(path, handler)
+	if replaced != nil {
+		for i, rt := range r.routes {
+			if rt == replaced {
+				r.routes[i] = route
+			}
+		}
+		if root := r.root(); replaced.name != "" && root.names[replaced.name] == replaced {
+			delete(root.names, replaced.name)
+		}
+		r.indexPath(method, path)
 	} else {
-		for _, p := range optionalPaths {
-			tree.Add(p, handler)
+		r.routes = append(r.routes, route)
+		if r.byPath == nil {
+			r.byPath = make(map[string][]*Route)
 		}
+		r.byPath[key] = append(same, route)
+	}
+
+	if len(same) > 0 {
+		// the route is added to the routes with matchers of the path
+		r.rebuild(t, method)
+	} else {
+		addToTree(tree, treePath(path, urlParts), treeValue([]*Route{route}))
 	}
+	r.store(t, method)
+
+	return route
 }
 
 // Lookup allows the manual lookup of a method + path combo.
 // This is e.g. useful to build a framework around this router.
-// If the path was found, it returns the handler function and the path parameter
-// values. Otherwise the third return value indicates whether a redirection to
-// the same path with an extra / without the trailing slash should be performed.
-func (r *Router) Lookup(method, path string, v view.MethodViewer) (view.MethodViewer, bool) {
-	if tree := r.trees[method]; tree != nil {
-		handler, tsr := tree.Get(path, v.GetCtx())
+// If the path was found, it returns the handler function and appends the path parameter
+// values to params, which may be nil. Otherwise the second return value indicates whether
+// a redirection to the same path with an extra / without the trailing slash should be performed.
+// Matchers of routes are not evaluated. It freezes the router.
+func (r *Router) Lookup(method, path string, params *view.Params) (view.MethodViewer, bool) {
+	r.root().freeze()
+	t := r.load()
+
+	if tree := t.trees[method]; tree != nil {
+		handler, tsr := lookup(tree, path, params, nil)
 		if handler != nil || tsr {
 			return handler, tsr
 		}
 	}
 
-	if tree := r.trees[MethodWild]; tree != nil {
-		return tree.Get(path, v.GetCtx())
+	if tree := t.trees[MethodWild]; tree != nil {
+		return lookup(tree, path, params, nil)
 	}
 
 	return nil, false
@@ -246,56 +443,6 @@ func (r *Router) recv(ctx *fasthttp.RequestCtx) {
 	}
 }
 
-func (r *Router) allowed(path, reqMethod string) (allow string) {
-	allowed := make([]string, 0, 9)
-
-	if path == "*" || path == "/*" { // server-wide{ // server-wide
-		// empty method is used for internal calls to refresh the cache
-		if reqMethod == "" {
-			for method := range r.registeredPaths {
-				if method == fasthttp.MethodOptions {
-					continue
-				}
-				// Add request method to list of allowed methods
-				allowed = append(allowed, method)
-			}
-		} else {
-			return r.globalAllowed
-		}
-	} else { // specific path
-		for method := range r.trees {
-			// Skip the requested method - we already tried this one
-			if method == reqMethod || method == fasthttp.MethodOptions {
-				continue
-			}
-
-			handle, _ := r.trees[method].Get(path, nil)
-			if handle != nil {
-				// Add request method to list of allowed methods
-				allowed = append(allowed, method)
-			}
-		}
-	}
-
-	if len(allowed) > 0 {
-		// Add request method to list of allowed methods
-		allowed = append(allowed, fasthttp.MethodOptions)
-
-		// Sort allowed methods.
-		// sort.Strings(allowed) unfortunately causes unnecessary allocations
-		// due to allowed being moved to the heap and interface conversion
-		for i, l := 1, len(allowed); i < l; i++ {
-			for j := i; j > 0 && allowed[j] < allowed[j-1]; j-- {
-				allowed[j], allowed[j-1] = allowed[j-1], allowed[j]
-			}
-		}
-
-		// return as comma separated list
-		return strings.Join(allowed, ", ")
-	}
-	return
-}
-
 func (r *Router) tryRedirect(ctx *fasthttp.RequestCtx, tree *radix.Tree, tsr bool, method, path string) bool {
 	// Moved Permanently, request with GET method
 	code := fasthttp.StatusMovedPermanently
@@ -320,6 +467,7 @@ func (r *Router) tryRedirect(ctx *fasthttp.RequestCtx, tree *radix.Tree, tsr boo
 			uri.Write(queryBuf)
 		}
 
+		r.logRouting("%s %s: redirected to %s", method, path, uri)
 		ctx.Redirect(uri.String(), code)
 
 		bytebufferpool.Put(uri)
@@ -336,13 +484,15 @@ func (r *Router) tryRedirect(ctx *fasthttp.RequestCtx, tree *radix.Tree, tsr boo
 			uri,
 		)
 
-		if found {
+		// the path itself is found if its parameters can't be converted
+		if found && string(uri.B) != path {
 			queryBuf := ctx.URI().QueryString()
 			if len(queryBuf) > 0 {
 				uri.WriteByte(questionMark)
 				uri.Write(queryBuf)
 			}
 
+			r.logRouting("%s %s: redirected to fixed path %s", method, path, uri)
 			ctx.RedirectBytes(uri.Bytes(), code)
 
 			bytebufferpool.Put(uri)
@@ -356,22 +506,214 @@ func (r *Router) tryRedirect(ctx *fasthttp.RequestCtx, tree *radix.Tree, tsr boo
 
 // Handler makes the router implement the http.Handler interface.
 func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
+	r.root().freeze()
 	if r.PanicHandler != nil {
 		defer r.recv(ctx)
 	}
+	if r.Views != nil {
+		view.SetOptions(ctx, r.Views)
+	}
 
+	ps := paramsPool.Get().(*view.Params)
+	defer func() {
+		*ps = (*ps)[:0]
+		paramsPool.Put(ps)
+	}()
+
+	rt := r
+	if len(r.hosts) > 0 {
+		if hr := r.matchHost(ctx, ps); hr != nil {
+			rt = hr
+		}
+		setUserValues(ctx, *ps)
+	}
+
+	r.serve(ctx, rt, ps)
+}
+
+// setUserValues saves the params as ctx.UserValue, converted params are saved as typed value
+func setUserValues(ctx *fasthttp.RequestCtx, ps view.Params) {
+	for _, p := range ps {
+		if p.Typed != nil {
+			ctx.SetUserValue(p.Key, p.Typed)
+		} else {
+			ctx.SetUserValue(p.Key, p.Value)
+		}
+	}
+}
+
+// dispatch runs a copy of the handler of the matched route,
+// params holds the host and path parameters of the request
+func (r *Router) dispatch(ctx *fasthttp.RequestCtx, handler view.MethodViewer, params view.Params) {
+	if version, ok := params.Get(VersionParam); ok {
+		r.Versioning.setHeaders(ctx, version)
+	}
+
+	defer r.recover(ctx, handler)
+
+	if h, ok := handler.(*HandlerView); ok {
+		h.ServeFastHTTP(ctx)
+		return
+	}
+
+	switcher := view.Switcher
+	if rv, ok := handler.(*resourceView); ok {
+		handler, switcher = rv.resource, rv.switcher
+	}
+
+	// Deep copy, fix that when concurrent calls are made, handler reuse will cause ctx to be incorrect
+	copiedHandler := deepcopy.Copy(handler)
+	if newHandler, ok := copiedHandler.(view.MethodViewer); ok {
+		newHandler.Init()
+		newHandler.SetLogger(r.Logger)
+		newHandler.SetCtx(ctx)
+		if u, ok := newHandler.(urlFuncSetter); ok {
+			u.SetURLFunc(r.URL)
+		}
+		if p, ok := newHandler.(paramsSetter); ok {
+			p.SetParams(params)
+		}
+		if st, ok := newHandler.(sessionStoreSetter); ok && r.Sessions != nil {
+			st.SetSessionStore(r.Sessions)
+		}
+		if err := switcher(newHandler); err != nil {
+			r.handleError(ctx, view.AsHTTPError(err), newHandler)
+		}
+	}
+}
+
+// recover renders HTTPErrors raised by the handler, other panics are passed to the
+// PanicHandler or answered with 500 Internal Server Error
+func (r *Router) recover(ctx *fasthttp.RequestCtx, handler view.MethodViewer) {
+	rcv := recover()
+	if rcv == nil {
+		return
+	}
+
+	if err, ok := rcv.(error); ok {
+		var e *view.HTTPError
+		if errors.As(err, &e) {
+			r.handleError(ctx, e, handler)
+			return
+		}
+	}
+
+	if r.PanicHandler != nil {
+		if rt := r.routeOf(handler); rt != nil {
+			ctx.SetUserValue(MatchedRouteParam, rt.Info())
+		}
+		r.PanicHandler(ctx, rcv)
+		return
+	}
+
+	e := view.NewHTTPError(fasthttp.StatusInternalServerError, "")
+	e.Err = fmt.Errorf("panic: %v", rcv)
+	r.handleError(ctx, e, handler)
+}
+
+// routeOf returns the route of the router or its hosts registered with the handler
+func (r *Router) routeOf(handler view.MethodViewer) *Route {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	return r.findRoute(handler)
+}
+
+func (r *Router) findRoute(handler view.MethodViewer) *Route {
+	for _, rt := range r.routes {
+		if rt.handler == handler {
+			return rt
+		}
+	}
+	for _, vh := range r.hosts {
+		if rt := vh.router.findRoute(handler); rt != nil {
+			return rt
+		}
+	}
+
+	return nil
+}
+
+// handleError logs server errors and renders the error, handler is nil if no route matches
+func (r *Router) handleError(ctx *fasthttp.RequestCtx, err *view.HTTPError, handler view.MethodViewer) {
+	if err.Status >= fasthttp.StatusInternalServerError && r.Logger != nil {
+		r.Logger.Errorf("%s %s: %s", ctx.Method(), ctx.Path(), err)
+	}
+
+	r.RenderError(ctx, err, handler)
+}
+
+// RenderError renders the error by the ErrorHandler without logging it, e.g. for errors
+// of middlewares, handler is the view of the matched route or nil
+func (r *Router) RenderError(ctx *fasthttp.RequestCtx, err *view.HTTPError, handler view.MethodViewer) {
+	handler = viewOf(handler)
+	switch {
+	case r.ErrorHandler != nil:
+		r.ErrorHandler(ctx, err, handler)
+	case r.ProblemDetails:
+		ProblemErrorHandler(ctx, err, handler)
+	default:
+		DefaultErrorHandler(ctx, err, handler)
+	}
+}
+
+// DefaultErrorHandler renders errors as JSON for views rendering JSON like view.ApiView,
+// as html for other views. Errors of requests which don't match a view are rendered as
+// JSON if the client prefers it.
+func DefaultErrorHandler(ctx *fasthttp.RequestCtx, err *view.HTTPError, v view.MethodViewer) {
+	var asJSON bool
+	switch v.(type) {
+	case nil, *HandlerView:
+		asJSON = view.AcceptsJSON(ctx)
+	case interface{ JsonRender() }:
+		asJSON = true
+	}
+
+	if asJSON {
+		view.WriteJSONError(ctx, err)
+	} else {
+		view.WriteHTMLError(ctx, err)
+	}
+}
+
+// ProblemErrorHandler renders errors as problem details documents, except errors of views
+// rendering html and errors of requests which don't match a view if the client prefers html
+func ProblemErrorHandler(ctx *fasthttp.RequestCtx, err *view.HTTPError, v view.MethodViewer) {
+	asHTML := false
+	switch v.(type) {
+	case nil, *HandlerView:
+		asHTML = bytes.Contains(ctx.Request.Header.Peek("Accept"), []byte("text/html")) && !view.AcceptsJSON(ctx)
+	case interface{ JsonRender() }:
+	default:
+		asHTML = true
+	}
+
+	if asHTML {
+		view.WriteHTMLError(ctx, err)
+	} else {
+		view.WriteProblem(ctx, err)
+	}
+}
+
+// serve dispatches the request to the routes of rt, which is the router itself or one of
+// its virtual hosts. Settings, except the NotFound handler, are taken from the router.
+// ps holds the parameters captured from the host.
+func (r *Router) serve(ctx *fasthttp.RequestCtx, rt *Router, ps *view.Params) {
 	path := gotils.B2S(ctx.Request.URI().Path())
 	method := gotils.B2S(ctx.Request.Header.Method())
-	if tree := r.trees[method]; tree != nil {
-		if handler, tsr := tree.Get(path, ctx); handler != nil {
-			// Deep copy, fix that when concurrent calls are made, handler reuse will cause ctx to be incorrect
-			copiedHandler := deepcopy.Copy(handler)
-			if newHandler, ok := copiedHandler.(view.MethodViewer); ok {
-				newHandler.Init()
-				newHandler.SetLogger(r.Logger)
-				newHandler.SetCtx(ctx)
-				view.Switcher(newHandler)
+
+	// the table is loaded once so the request is served by a consistent set of routes
+	t := rt.load()
+	hostParams := len(*ps)
+
+	if tree := t.trees[method]; tree != nil {
+		if handler, tsr := lookup(tree, path, ps, ctx); handler != nil {
+			setUserValues(ctx, (*ps)[hostParams:])
+			if r.LogRouting {
+				r.logRouting("%s %s: matched %T %v", method, path, handler, (*ps)[hostParams:])
 			}
+			r.dispatch(ctx, handler, *ps)
 			return
 		} else if method != fasthttp.MethodConnect && path != "/" {
 			if ok := r.tryRedirect(ctx, tree, tsr, method, path); ok {
@@ -381,16 +723,13 @@ func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
 	}
 
 	// Try to search in the wild method tree
-	if tree := r.trees[MethodWild]; tree != nil {
-		if handler, tsr := tree.Get(path, ctx); handler != nil {
-			// Deep copy, fix that when concurrent calls are made, handler reuse will cause ctx to be incorrect
-			copiedHandler := deepcopy.Copy(handler)
-			if newHandler, ok := copiedHandler.(view.MethodViewer); ok {
-				newHandler.Init()
-				newHandler.SetLogger(r.Logger)
-				newHandler.SetCtx(ctx)
-				view.Switcher(newHandler)
+	if tree := t.trees[MethodWild]; tree != nil {
+		if handler, tsr := lookup(tree, path, ps, ctx); handler != nil {
+			setUserValues(ctx, (*ps)[hostParams:])
+			if r.LogRouting {
+				r.logRouting("%s %s: matched %T of any method %v", method, path, handler, (*ps)[hostParams:])
 			}
+			r.dispatch(ctx, handler, *ps)
 			return
 		} else if method != fasthttp.MethodConnect && path != "/" {
 			if ok := r.tryRedirect(ctx, tree, tsr, method, path); ok {
@@ -402,7 +741,8 @@ func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
 	if r.HandleOPTIONS && method == fasthttp.MethodOptions {
 		// Handle OPTIONS requests
 
-		if allow := r.allowed(path, fasthttp.MethodOptions); allow != "" {
+		if allow := t.allowed(path, fasthttp.MethodOptions); allow != "" {
+			r.logRouting("%s %s: answered OPTIONS, allowed %s", method, path, allow)
 			ctx.Response.Header.Set("Allow", allow)
 			if r.GlobalOPTIONS != nil {
 				r.GlobalOPTIONS(ctx)
@@ -412,22 +752,50 @@ func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
 	} else if r.HandleMethodNotAllowed {
 		// Handle 405
 
-		if allow := r.allowed(path, method); allow != "" {
+		if allow := t.allowed(path, method); allow != "" {
+			r.logRouting("%s %s: method not allowed, allowed %s", method, path, allow)
 			ctx.Response.Header.Set("Allow", allow)
 			if r.MethodNotAllowed != nil {
 				r.MethodNotAllowed(ctx)
 			} else {
-				ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
-				ctx.SetBodyString(fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed))
+				r.handleError(ctx, view.NewHTTPError(fasthttp.StatusMethodNotAllowed, ""), nil)
 			}
 			return
 		}
 	}
 
+	// Handle 501 for methods the server doesn't know
+	if !knownMethod(method) && t.trees[method] == nil {
+		r.logRouting("%s %s: method not implemented", method, path)
+		r.handleError(ctx, view.NewHTTPError(fasthttp.StatusNotImplemented, ""), nil)
+		return
+	}
+
 	// Handle 404
-	if r.NotFound != nil {
+	r.logRouting("%s %s: no route found", method, path)
+	if rt.NotFound != nil {
+		rt.NotFound(ctx)
+	} else if r.NotFound != nil {
 		r.NotFound(ctx)
 	} else {
-		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusNotFound), fasthttp.StatusNotFound)
+		r.handleError(ctx, view.NewHTTPError(fasthttp.StatusNotFound, ""), nil)
+	}
+}
+
+// logRouting logs a routing decision if LogRouting is enabled
+func (r *Router) logRouting(format string, args ...interface{}) {
+	if r.LogRouting && r.Logger != nil {
+		r.Logger.Infof(format, args...)
 	}
 }
+
+// knownMethod reports whether the method is a standard method
+func knownMethod(method string) bool {
+	switch method {
+	case fasthttp.MethodGet, fasthttp.MethodHead, fasthttp.MethodPost, fasthttp.MethodPut,
+		fasthttp.MethodPatch, fasthttp.MethodDelete, fasthttp.MethodConnect,
+		fasthttp.MethodOptions, fasthttp.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+func TestRouting(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/articles", text("list"))
+	r.HandleFunc(fasthttp.MethodPost, "/articles", text("create"))
+	r.HandleFunc(fasthttp.MethodGet, "/dir/", text("dir"))
+	r.HandleFunc(fasthttp.MethodPut, "/dir/", text("dir"))
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		body     string
+		allow    string
+		location string
+	}{
+		{fasthttp.MethodGet, "/articles", fasthttp.StatusOK, "list", "", ""},
+		{fasthttp.MethodPost, "/articles", fasthttp.StatusOK, "create", "", ""},
+		{fasthttp.MethodDelete, "/articles", fasthttp.StatusMethodNotAllowed, "", "GET, OPTIONS, POST", ""},
+		{fasthttp.MethodOptions, "/articles", fasthttp.StatusOK, "", "GET, OPTIONS, POST", ""},
+		{fasthttp.MethodOptions, "*", fasthttp.StatusOK, "", "GET, OPTIONS, POST, PUT", ""},
+		{fasthttp.MethodGet, "/dir", fasthttp.StatusMovedPermanently, "", "", "http:///dir/"},
+		{fasthttp.MethodPut, "/dir", fasthttp.StatusPermanentRedirect, "", "", "http:///dir/"},
+		{fasthttp.MethodGet, "/DIR/", fasthttp.StatusMovedPermanently, "", "", "http:///dir/"},
+		{fasthttp.MethodGet, "/missing", fasthttp.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI(tt.path)
+			r.Handler(ctx)
+
+			if status := ctx.Response.StatusCode(); status != tt.status {
+				t.Errorf("status was %d; want %d", status, tt.status)
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("body was %q; want %q", ctx.Response.Body(), tt.body)
+			}
+			if allow := string(ctx.Response.Header.Peek(fasthttp.HeaderAllow)); allow != tt.allow {
+				t.Errorf("allow was %q; want %q", allow, tt.allow)
+			}
+			if location := string(ctx.Response.Header.Peek(fasthttp.HeaderLocation)); location != tt.location {
+				t.Errorf("location was %q; want %q", location, tt.location)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/users/{user}/posts/{post}", text("post"))
+	r.HandleFunc(fasthttp.MethodGet, "/dir/", text("dir"))
+
+	tests := []struct {
+		path   string
+		found  bool
+		params view.Params
+		tsr    bool
+	}{
+		{"/users/ann/posts/7", true, view.Params{{Key: "user", Value: "ann"}, {Key: "post", Value: "7"}}, false},
+		{"/users/ann/posts/7/", false, nil, true},
+		{"/dir", false, nil, true},
+		{"/users/ann", false, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var params view.Params
+			handler, tsr := r.Lookup(fasthttp.MethodGet, tt.path, &params)
+			if (handler != nil) != tt.found {
+				t.Errorf("found was %t; want %t", handler != nil, tt.found)
+			}
+			if tt.found && !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params were %v; want %v", params, tt.params)
+			}
+			if tsr != tt.tsr {
+				t.Errorf("tsr was %t; want %t", tsr, tt.tsr)
+			}
+		})
+	}
+}
+
+func TestDuplicateParamPanics(t *testing.T) {
+	tests := []string{
+		"/users/{id}/posts/{id}",
+		"/files/{name}.{name}",
+		"/geo/{at},{at:[0-9.]+}",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %s didn't panic", path)
+				}
+			}()
+			New().HandleFunc(fasthttp.MethodGet, path, text(""))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xxxmailk/cera/view"
+)
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method      string      `json:"method"`
+	Host        string      `json:"host,omitempty"`
+	Path        string      `json:"path"`
+	Name        string      `json:"name,omitempty"`
+	Params      []ParamInfo `json:"params,omitempty"`
+	View        string      `json:"view"`
+	Version     string      `json:"version,omitempty"`
+	Matchers    []string    `json:"matchers,omitempty"`
+	Middlewares []string    `json:"middlewares,omitempty"`
+
+	// the registered view, it's used by document generators
+	Handler view.MethodViewer `json:"-"`
+}
+
+// ParamInfo describes a path parameter of a route
+type ParamInfo struct {
+	Name      string `json:"name"`
+	Pattern   string `json:"pattern,omitempty"`   // regex constraint, empty if any value matches
+	Converter string `json:"converter,omitempty"` // name of the converter, e.g. "int"
+	Optional  bool   `json:"optional,omitempty"`
+	CatchAll  bool   `json:"catch_all,omitempty"`
+}
+
+// Info returns the description of the route
+func (rt *Route) Info() RouteInfo {
+	info := RouteInfo{
+		Method:   rt.method,
+		Host:     rt.router.host,
+		Path:     rt.path,
+		Name:     rt.name,
+		View:     reflect.TypeOf(viewOf(rt.handler)).String(),
+		Version:  rt.version,
+		Matchers: matcherNames(rt.matchers),
+
+		Handler: viewOf(rt.handler),
+	}
+
+	for _, p := range rt.urlParts {
+		if p.key == "" {
+			continue
+		}
+
+		info.Params = append(info.Params, ParamInfo{
+			Name:      p.key,
+			Pattern:   p.pattern,
+			Converter: p.converterName,
+			Optional:  p.optional,
+			CatchAll:  p.catchAll,
+		})
+	}
+
+	return info
+}
+
+// Routes returns the route table of the router and all its hosts,
+// sorted by host, path and method
+func (r *Router) Routes() []RouteInfo {
+	root := r.root()
+	root.mu.RLock()
+	routes := r.routeInfos()
+	root.mu.RUnlock()
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+
+	return routes
+}
+
+// Generation returns a counter of the route changes of the router and all its hosts,
+// it's increased whenever routes or hosts are registered, replaced or removed. It tells
+// whether data derived from Routes, e.g. an API document, is outdated.
+func (r *Router) Generation() uint64 {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	return root.generation
+}
+
+func (r *Router) routeInfos() []RouteInfo {
+	var routes []RouteInfo
+
+	for _, rt := range r.routes {
+		routes = append(routes, rt.Info())
+	}
+
+	for _, vh := range r.hosts {
+		routes = append(routes, vh.router.routeInfos()...)
+	}
+
+	return routes
+}
+
+// DumpRoutes writes the route table to w, format is "table" or "json"
+func (r *Router) DumpRoutes(w io.Writer, format string) error {
+	return DumpRoutes(w, format, r.Routes())
+}
+
+// DumpRoutes writes the routes to w, format is "table" or "json"
+func DumpRoutes(w io.Writer, format string, routes []RouteInfo) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	case "table", "":
+	default:
+		return fmt.Errorf("unknown routes format '%s', table or json", format)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tHOST\tPATH\tNAME\tVIEW\tVERSION\tPARAMS\tMATCHERS\tMIDDLEWARES")
+
+	for _, rt := range routes {
+		params := make([]string, 0, len(rt.Params))
+		for _, p := range rt.Params {
+			s := p.Name
+			switch {
+			case p.CatchAll:
+				s += ":*"
+			case p.Converter != "":
+				s += ":" + p.Converter
+			case p.Pattern != "":
+				s += ":" + p.Pattern
+			}
+			if p.Optional {
+				s += "?"
+			}
+			params = append(params, s)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			rt.Method,
+			orDash(rt.Host),
+			rt.Path,
+			orDash(rt.Name),
+			rt.View,
+			orDash(rt.Version),
+			orDash(strings.Join(params, " ")),
+			orDash(strings.Join(rt.Matchers, " ")),
+			orDash(strings.Join(rt.Middlewares, ", ")),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.GET("/users/{id:int}/{tab?}", &linkView{}).Name("user")
+	r.HandleFunc(fasthttp.MethodPost, "/users", text("create"))
+	r.HandleFunc(fasthttp.MethodGet, "/users", text("list")).Match(Query("page"))
+	r.Versioning = Versioning{Header: "X-API-Version"}
+	r.Version("v1").HandleFunc(fasthttp.MethodGet, "/status", text("v1"))
+	r.Host("api.example.com").HandleFunc(fasthttp.MethodGet, "/files/{path:*}", text("file"))
+
+	want := []RouteInfo{
+		{Method: "GET", Path: "/status", View: "*router.HandlerView", Version: "v1", Matchers: []string{"version(v1)"}},
+		{Method: "GET", Path: "/users", View: "*router.HandlerView", Matchers: []string{"query(page)"}},
+		{Method: "POST", Path: "/users", View: "*router.HandlerView"},
+		{
+			Method: "GET", Path: "/users/{id:int}/{tab?}", Name: "user", View: "*router.linkView",
+			Params: []ParamInfo{{Name: "id", Pattern: "-?[0-9]+", Converter: "int"}, {Name: "tab", Optional: true}},
+		},
+		{
+			Method: "GET", Host: "api.example.com", Path: "/files/{path:*}", View: "*router.HandlerView",
+			Params: []ParamInfo{{Name: "path", CatchAll: true}},
+		},
+	}
+
+	got := r.Routes()
+	for i := range got {
+		got[i].Handler = nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes were\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDumpRoutes(t *testing.T) {
+	r := New()
+	r.GET("/users/{id:int}", &linkView{}).Name("user")
+	r.HandleFunc(fasthttp.MethodGet, "/feed", text("feed")).Match(Header("Accept", "application/xml"))
+
+	buf := &bytes.Buffer{}
+	if err := r.DumpRoutes(buf, "table"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := [][]string{
+		{"METHOD", "HOST", "PATH", "NAME", "VIEW", "VERSION", "PARAMS", "MATCHERS", "MIDDLEWARES"},
+		{"GET", "-", "/feed", "-", "*router.HandlerView", "-", "-"},
+		{"GET", "-", "/users/{id:int}", "user", "*router.linkView", "-", "id:int", "-", "-"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("table was\n%s\nwant %d lines", buf, len(want))
+	}
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < len(want[i]) || !reflect.DeepEqual(fields[:len(want[i])], want[i]) {
+			t.Errorf("line %d was %q; want %q", i+1, line, strings.Join(want[i], " "))
+		}
+	}
+
+	buf.Reset()
+	if err := r.DumpRoutes(buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var routes []RouteInfo
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil {
+		t.Fatalf("json dump %s is invalid, %s", buf, err)
+	}
+	if len(routes) != 2 || routes[1].Name != "user" || routes[1].Params[0].Converter != "int" {
+		t.Errorf("json dump was %s", buf)
+	}
+
+	if err := r.DumpRoutes(buf, "yaml"); err == nil || !strings.Contains(err.Error(), "unknown routes format 'yaml'") {
+		t.Errorf("error of an unknown format was %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package router
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/router/radix"
+	"github.com/xxxmailk/cera/view"
+)
+
+// routeKey is the key of the routes of method and path in the index of routes by path
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// indexPath refreshes the routes of method and path in the index of routes by path
+func (r *Router) indexPath(method, path string) {
+	var same []*Route
+	for _, rt := range r.routes {
+		if rt.method == method && rt.path == path {
+			same = append(same, rt)
+		}
+	}
+
+	key := routeKey(method, path)
+	if len(same) == 0 {
+		delete(r.byPath, key)
+	} else {
+		r.byPath[key] = same
+	}
+}
+
+// routeTable is a snapshot of the routes of a router.
+// Once the router is frozen the table in use is never modified, changes are made to a copy
+// which then replaces it atomically, so routes could be changed while requests are served.
+type routeTable struct {
+	trees           map[string]*radix.Tree
+	registeredPaths map[string][]string
+
+	// Cached value of global (*) allowed methods
+	globalAllowed string
+}
+
+var emptyTable = &routeTable{}
+
+// load returns the routing table in use
+func (r *Router) load() *routeTable {
+	if t, ok := r.table.Load().(*routeTable); ok {
+		return t
+	}
+
+	return emptyTable
+}
+
+// Freeze publishes the routes registered so far. Until the router is frozen, routes are
+// registered in place which keeps registering many routes at startup cheap, afterwards
+// route changes are made on copies of the route table, see routeTable. The router is frozen
+// by the first request it serves, by Lookup and List, or by calling Freeze before requests
+// are served by other means.
+func (r *Router) Freeze() {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	if !root.isFrozen() {
+		root.sortTrees()
+		atomic.StoreInt32(&root.frozen, 1)
+	}
+}
+
+// sortTrees sorts the trees of the router and its hosts, nodes aren't sorted on adding
+// routes until the router is frozen
+func (r *Router) sortTrees() {
+	for _, tree := range r.load().trees {
+		tree.Sort()
+		tree.Unsorted = false
+	}
+	for _, vh := range r.hosts {
+		vh.router.sortTrees()
+	}
+}
+
+// freeze freezes the router unless it's frozen, r is the root router
+func (r *Router) freeze() {
+	if !r.isFrozen() {
+		r.Freeze()
+	}
+}
+
+// isFrozen reports whether the router is frozen, r is the root router
+func (r *Router) isFrozen() bool {
+	return atomic.LoadInt32(&r.frozen) == 1
+}
+
+// writable returns the table to change, the table in use until the router is frozen,
+// afterwards a copy of it. The caller holds the lock of the root router.
+func (r *Router) writable() *routeTable {
+	t := r.load()
+	if t == emptyTable || r.root().isFrozen() {
+		return t.copy()
+	}
+	return t
+}
+
+// store publishes the changed table t of method, the caller holds the lock of the root router
+func (r *Router) store(t *routeTable, method string) {
+	r.table.Store(t.done(method))
+	r.root().generation++
+}
+
+// copy returns a copy of the table, trees and paths are shared so the ones
+// which are changed have to be replaced
+func (t *routeTable) copy() *routeTable {
+	c := &routeTable{
+		trees:           make(map[string]*radix.Tree, len(t.trees)+1),
+		registeredPaths: make(map[string][]string, len(t.registeredPaths)+1),
+		globalAllowed:   t.globalAllowed,
+	}
+	for m, tree := range t.trees {
+		c.trees[m] = tree
+	}
+	for m, paths := range t.registeredPaths {
+		c.registeredPaths[m] = paths
+	}
+
+	return c
+}
+
+// done drops the tree of method if it has no routes and refreshes the cached allowed methods
+func (t *routeTable) done(method string) *routeTable {
+	if len(t.registeredPaths[method]) == 0 {
+		delete(t.trees, method)
+		delete(t.registeredPaths, method)
+	}
+	t.globalAllowed = t.allowed("*", "")
+
+	return t
+}
+
+func (t *routeTable) allowed(path, reqMethod string) (allow string) {
+	allowed := make([]string, 0, 9)
+
+	if path == "*" || path == "/*" { // server-wide
+		// empty method is used for internal calls to refresh the cache
+		if reqMethod == "" {
+			for method := range t.registeredPaths {
+				if method == fasthttp.MethodOptions {
+					continue
+				}
+				// Add request method to list of allowed methods
+				allowed = append(allowed, method)
+			}
+		} else {
+			return t.globalAllowed
+		}
+	} else { // specific path
+		for method := range t.trees {
+			// Skip the requested method - we already tried this one
+			if method == reqMethod || method == fasthttp.MethodOptions {
+				continue
+			}
+
+			handle, _ := t.trees[method].Get(path, nil)
+			if handle != nil {
+				// Add request method to list of allowed methods
+				allowed = append(allowed, method)
+			}
+		}
+	}
+
+	if len(allowed) > 0 {
+		// Add request method to list of allowed methods
+		allowed = append(allowed, fasthttp.MethodOptions)
+
+		// Sort allowed methods.
+		// sort.Strings(allowed) unfortunately causes unnecessary allocations
+		// due to allowed being moved to the heap and interface conversion
+		for i, l := 1, len(allowed); i < l; i++ {
+			for j := i; j > 0 && allowed[j] < allowed[j-1]; j-- {
+				allowed[j], allowed[j-1] = allowed[j-1], allowed[j]
+			}
+		}
+
+		// return as comma separated list
+		return strings.Join(allowed, ", ")
+	}
+	return
+}
+
+// addToTree adds the handler for the path and all the paths of its optional parameters
+func addToTree(tree *radix.Tree, path string, handler view.MethodViewer) {
+	optionalPaths := getOptionalPaths(path)
+
+	// if not has optional paths, adds the original
+	if len(optionalPaths) == 0 {
+		tree.Add(path, handler)
+	} else {
+		for _, p := range optionalPaths {
+			tree.Add(p, handler)
+		}
+	}
+}
+
+// rebuild replaces the tree and the paths of method in the table by the ones of the
+// registered routes
+func (r *Router) rebuild(t *routeTable, method string) {
+	tree := radix.New()
+	tree.Mutable = true
+	tree.Unsorted = true
+	var paths []string
+
+	// routes with the same path differ by matchers
+	same := make(map[string][]*Route)
+	for _, rt := range r.routes {
+		if rt.method != method {
+			continue
+		}
+		if len(same[rt.path]) == 0 {
+			paths = append(paths, rt.path)
+		}
+		same[rt.path] = append(same[rt.path], rt)
+	}
+
+	for _, path := range paths {
+		routes := same[path]
+		addToTree(tree, treePath(path, routes[0].urlParts), treeValue(routes))
+	}
+
+	tree.Mutable = r.treeMutable
+	if r.root().isFrozen() {
+		tree.Sort()
+		tree.Unsorted = false
+	}
+	t.trees[method] = tree
+	t.registeredPaths[method] = paths
+}
+
+// Remove removes the routes with the given method and path pattern, as it was registered,
+// and reports whether one existed, routes of the path with matchers are removed as well.
+// It's safe to remove routes while requests are served, requests already dispatched to
+// the route are completed.
+func (r *Router) Remove(method, path string) bool {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	return r.remove(method, path, func(*Route) bool { return true })
+}
+
+// remove removes the routes with the given method and path pattern which match,
+// the caller holds the lock of the root router
+func (r *Router) remove(method, path string, match func(rt *Route) bool) bool {
+	root := r.root()
+	routes := make([]*Route, 0, len(r.routes))
+	for _, rt := range r.routes {
+		if rt.method != method || rt.path != path || !match(rt) {
+			routes = append(routes, rt)
+			continue
+		}
+		if rt.name != "" && root.names[rt.name] == rt {
+			delete(root.names, rt.name)
+		}
+	}
+	if len(routes) == len(r.routes) {
+		return false
+	}
+
+	r.routes = routes
+	r.indexPath(method, path)
+	t := r.writable()
+	r.rebuild(t, method)
+	r.store(t, method)
+
+	return true
+}
+
+// Replace replaces the handler of the route without matchers with the given method and
+// path pattern, the route keeps its name. The route is registered if it doesn't exist.
+// It's safe to replace routes while requests are served and it doesn't need Mutable.
+func (r *Router) Replace(method, path string, handler view.MethodViewer) *Route {
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	return r.replace(&Route{method: method, path: path, handler: handler}, func(rt *Route) bool {
+		return len(rt.matchers) == 0
+	})
+}
+
+// replace replaces the handler of the registered route of the method and path of route
+// which matches, route is registered if there is none. The caller holds the lock of the
+// root router.
+func (r *Router) replace(route *Route, match func(rt *Route) bool) *Route {
+	root := r.root()
+	method, path, handler := route.method, route.path, route.handler
+
+	var found *Route
+	routes := make([]*Route, 0, len(r.routes))
+	for _, rt := range r.routes {
+		if rt.method == method && rt.path == path && match(rt) {
+			if found != nil {
+				// registered again on a mutable router
+				if rt.name != "" && root.names[rt.name] == rt {
+					delete(root.names, rt.name)
+				}
+				continue
+			}
+			found = rt
+		}
+		routes = append(routes, rt)
+	}
+	if found == nil {
+		return r.handle(route)
+	}
+
+	found.handler = handler
+	r.routes = routes
+	r.indexPath(method, path)
+
+	t := r.writable()
+	r.rebuild(t, method)
+	r.store(t, method)
+
+	return found
+}
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// text returns a handler writing s
+func text(s string) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(s)
+	}
+}
+
+// serve serves a GET request of path by r
+func serve(r *Router, path string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(path)
+	r.Handler(ctx)
+	return ctx
+}
+
+func TestFreeze(t *testing.T) {
+	r := New()
+	for i := 0; i < 100; i++ {
+		r.HandleFunc(fasthttp.MethodGet, "/items/"+strconv.Itoa(i), text(strconv.Itoa(i)))
+	}
+	// nodes are sorted on freezing, static segments have priority over parameters
+	r.HandleFunc(fasthttp.MethodGet, "/users/{name}", text("param"))
+	r.HandleFunc(fasthttp.MethodGet, "/users/me", text("static"))
+	if r.root().isFrozen() {
+		t.Fatal("router was frozen before serving")
+	}
+
+	before := r.load()
+	if ctx := serve(r, "/items/42"); string(ctx.Response.Body()) != "42" {
+		t.Fatalf("body was %q; want %q", ctx.Response.Body(), "42")
+	}
+	if !r.root().isFrozen() {
+		t.Fatal("router wasn't frozen by the first request")
+	}
+	for path, want := range map[string]string{"/users/me": "static", "/users/ann": "param"} {
+		if ctx := serve(r, path); string(ctx.Response.Body()) != want {
+			t.Errorf("body of %s was %q; want %q", path, ctx.Response.Body(), want)
+		}
+	}
+
+	r.HandleFunc(fasthttp.MethodGet, "/late", text("late"))
+	if r.load() == before {
+		t.Error("route registered on a frozen router didn't replace the table in use")
+	}
+	if paths := before.registeredPaths[fasthttp.MethodGet]; len(paths) != 102 {
+		t.Errorf("former table has %d paths after a late registration; want 102", len(paths))
+	}
+	if ctx := serve(r, "/late"); string(ctx.Response.Body()) != "late" {
+		t.Errorf("body was %q; want %q", ctx.Response.Body(), "late")
+	}
+}
+
+func TestRouteChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Router, i int)
+		want   map[string]bool // bodies of /articles served while it changes, "" is 404
+	}{
+		{
+			name: "replace",
+			change: func(r *Router, i int) {
+				r.Replace(fasthttp.MethodGet, "/articles", &HandlerView{handler: text("v" + strconv.Itoa(i%2))})
+			},
+			want: map[string]bool{"v0": true, "v1": true},
+		},
+		{
+			name: "remove and add",
+			change: func(r *Router, i int) {
+				if !r.Remove(fasthttp.MethodGet, "/articles") {
+					t.Error("remove of a registered route returned false")
+				}
+				r.HandleFunc(fasthttp.MethodGet, "/articles", text("v0"))
+			},
+			want: map[string]bool{"v0": true, "": true},
+		},
+		{
+			name: "add other routes",
+			change: func(r *Router, i int) {
+				r.HandleFunc(fasthttp.MethodGet, "/other/"+strconv.Itoa(i), text("other"))
+			},
+			want: map[string]bool{"v0": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.HandleFunc(fasthttp.MethodGet, "/articles", text("v0"))
+			r.Freeze()
+
+			done := make(chan struct{})
+			wg := sync.WaitGroup{}
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						ctx := serve(r, "/articles")
+						body := string(ctx.Response.Body())
+						if ctx.Response.StatusCode() == fasthttp.StatusNotFound {
+							body = ""
+						}
+						if !tt.want[body] {
+							t.Errorf("body was %q while routes changed", body)
+							return
+						}
+					}
+				}()
+			}
+
+			for i := 0; i < 200; i++ {
+				tt.change(r, i)
+			}
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func TestRegisterAgain(t *testing.T) {
+	tests := []struct {
+		name     string
+		frozen   bool
+		register func(r *Router)
+	}{
+		{
+			name: "mutable",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodGet, "/articles", text("v2")).Name("articles")
+			},
+		},
+		{
+			name:   "mutable frozen",
+			frozen: true,
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodGet, "/articles", text("v2")).Name("articles")
+			},
+		},
+		{
+			name: "replace",
+			register: func(r *Router) {
+				r.Replace(fasthttp.MethodGet, "/articles", &HandlerView{handler: text("v2")})
+			},
+		},
+		{
+			name: "replace mutable registration",
+			register: func(r *Router) {
+				r.HandleFunc(fasthttp.MethodGet, "/articles", text("v1")).Name("articles")
+				r.Replace(fasthttp.MethodGet, "/articles", &HandlerView{handler: text("v2")})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.Mutable(true)
+			r.HandleFunc(fasthttp.MethodGet, "/articles", text("v1")).Name("articles")
+			r.Match(Query("q")).HandleFunc(fasthttp.MethodGet, "/articles", text("search"))
+			if tt.frozen {
+				r.Freeze()
+			}
+			tt.register(r)
+
+			if ctx := serve(r, "/articles"); string(ctx.Response.Body()) != "v2" {
+				t.Errorf("body was %q; want %q", ctx.Response.Body(), "v2")
+			}
+			if ctx := serve(r, "/articles?q"); string(ctx.Response.Body()) != "search" {
+				t.Errorf("body was %q; want %q", ctx.Response.Body(), "search")
+			}
+			if routes := r.Routes(); len(routes) != 2 {
+				t.Errorf("routes were %+v; want the route and the route with matchers", routes)
+			}
+			if paths := r.load().registeredPaths[fasthttp.MethodGet]; len(paths) != 1 {
+				t.Errorf("registered paths were %v; want /articles once", paths)
+			}
+			if url, err := r.URL("articles"); err != nil || url != "/articles" {
+				t.Errorf("url was %q, %v; want /articles", url, err)
+			}
+		})
+	}
+}
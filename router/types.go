@@ -1,20 +1,35 @@
 package router
 
 import (
+	"sync"
+	"sync/atomic"
+
 	"github.com/valyala/fasthttp"
 	"github.com/xxxmailk/cera/log"
-	"github.com/xxxmailk/cera/router/radix"
+	"github.com/xxxmailk/cera/view"
 )
 
 // Router is a fasthttp.RequestHandler which can be used to dispatch requests to different
 // handler functions via configurable routes
 type Router struct {
-	trees           map[string]*radix.Tree
-	treeMutable     bool
-	registeredPaths map[string][]string
+	// The *routeTable in use, it's replaced as a whole when routes change
+	table       atomic.Value
+	treeMutable bool
+
+	// Serializes route changes, only the mutex of the root router is used
+	// so it's shared by the router and all its hosts
+	mu sync.RWMutex
+
+	// Set to 1 once the router is frozen, see Freeze. Only the flag of the root router
+	// is used, it's set under mu and read atomically.
+	frozen int32
+
+	// Counts the route changes of the router and all its hosts, see Generation.
+	// Only the counter of the root router is used, it's guarded by mu.
+	generation uint64
 
 	// If enabled, adds the matched route path onto the ctx.UserValue context
-	// before invoking the handler.
+	// and the view params under MatchedRoutePathParam before invoking the handler.
 	// The matched route path is only added to handlers of routes that were
 	// registered when this option was enabled.
 	SaveMatchedRoutePath bool
@@ -66,15 +81,61 @@ type Router struct {
 	// is called.
 	MethodNotAllowed fasthttp.RequestHandler
 
+	// Configurable handler rendering the errors views fail with or raise, panics and
+	// 404, 405 and 501 responses the NotFound and MethodNotAllowed handlers don't answer.
+	// v is the view of the matched route, nil if no route matches.
+	// If it is not set, ProblemErrorHandler is used if ProblemDetails is enabled,
+	// DefaultErrorHandler otherwise.
+	ErrorHandler func(ctx *fasthttp.RequestCtx, err *view.HTTPError, v view.MethodViewer)
+
+	// If enabled, errors are rendered as application/problem+json documents (RFC 7807)
+	// instead of JSON, and errors of requests not served by a view unless the client
+	// prefers html.
+	ProblemDetails bool
+
 	// Function to handle panics recovered from http handlers.
 	// It should be used to generate a error page and return the http error code
 	// 500 (Internal Server Error).
 	// The handler can be used to keep your server from crashing because of
-	// unrecovered panics.
+	// unrecovered panics. If it is not set, panics of views and handlers of routes
+	// are answered with 500 Internal Server Error by the ErrorHandler.
 	PanicHandler func(*fasthttp.RequestCtx, interface{})
 
-	// Cached value of global (*) allowed methods
-	globalAllowed string
+	// If enabled, the port of the request host is ignored when matching
+	// hosts registered with Router.Host, e.g. the request host
+	// "example.com:8080" matches the host "example.com".
+	// Host patterns with a port never match when it's enabled.
+	HostIgnorePort bool
+
+	// If enabled, routing decisions like matched routes, redirects and 404, 405 and
+	// 501 responses are logged with the Logger at info level.
+	LogRouting bool
+
+	// Store of the sessions views create by View.Sessions, sessions live for
+	// view.DefaultSessionLifeCycle if it is not set.
+	Sessions *view.SessionStore
+
+	// Rendering options of the views and the error handlers, e.g. the JSON encoder
+	// and envelope, the zero view.Options are used if it is not set.
+	Views *view.Options
+
+	// Selects the API version of requests to routes registered with Router.Version,
+	// the versioning of the router is used by all its hosts.
+	Versioning Versioning
+
+	// Virtual hosts registered with Router.Host
+	hosts []*virtualHost
+
+	// The router this virtual host router belongs to, nil for the router itself
+	parent *Router
+	host   string
+
+	// Registered routes in registration order and named routes
+	routes []*Route
+	names  map[string]*Route
+
+	// Routes by method and path, see routeKey
+	byPath map[string][]*Route
 
 	Logger log.SimpleLogger
 }
@@ -83,4 +144,15 @@ type Router struct {
 type Group struct {
 	router *Router
 	prefix string
+
+	// matchers and API version of the routes of the group, see Router.Version
+	matchers []Matcher
+	version  string
+
+	// prefix without the version prefix
+	base string
+
+	// matcher of the routes of a version served without the version prefix too,
+	// nil if they are only served under the prefix
+	unprefixed Matcher
 }
@@ -0,0 +1,184 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPart is a literal part or a parameter of a route path pattern
+type urlPart struct {
+	literal  string
+	key      string
+	optional bool
+	catchAll bool
+	pattern  string
+	regex    *regexp.Regexp
+
+	// converter named by the pattern, the pattern is the regex of the converter
+	converter     *Converter
+	converterName string
+}
+
+// parseURLPattern splits a route path pattern into literals and parameters
+func parseURLPattern(path string) ([]urlPart, error) {
+	var parts []urlPart
+
+	for len(path) > 0 {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			parts = append(parts, urlPart{literal: path})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, urlPart{literal: path[:start]})
+		}
+
+		// find the closing brace, braces are allowed in the regex
+		end, depth := -1, 0
+		for i := start + 1; i < len(path) && end < 0; i++ {
+			switch path[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					end = i
+				}
+				depth--
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed parameter in path '%s'", path)
+		}
+
+		p := urlPart{key: path[start+1 : end]}
+		pattern := ""
+		if i := strings.IndexByte(p.key, ':'); i >= 0 {
+			p.key, pattern = p.key[:i], p.key[i+1:]
+		}
+		if strings.HasSuffix(p.key, "?") {
+			p.key = p.key[:len(p.key)-1]
+			p.optional = true
+		}
+
+		if c := converter(pattern); c != nil {
+			p.converter, p.converterName = c, pattern
+			pattern = c.Regex
+		}
+
+		switch pattern {
+		case "":
+		case "*":
+			p.catchAll = true
+		default:
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				return nil, err
+			}
+			p.pattern = pattern
+			p.regex = re
+		}
+
+		parts = append(parts, p)
+		path = path[end+1:]
+	}
+
+	return parts, nil
+}
+
+// URL generates the url of the route with the given name.
+// params are pairs of parameter name and value, values are formatted with fmt.Sprint,
+// or the Format function of the converter of the parameter, and escaped. Values of parameters with regex constraints must match the regex.
+// Optional parameters ({name?}) may be omitted, together with all following ones.
+// Pairs which are not parameters of the route are added as query string.
+// Use:
+//     r.GET("/articles/{id:[0-9]+}/{slug?}", &Article{}).Name("article")
+//     r.URL("article", "id", 42, "slug", "hello world") // "/articles/42/hello%20world"
+//     r.URL("article", "id", 42, "page", 2)             // "/articles/42?page=2"
+func (r *Router) URL(name string, params ...interface{}) (string, error) {
+	root := r.root()
+	root.mu.RLock()
+	rt, ok := root.names[name]
+	root.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no route named '%s'", name)
+	}
+	if len(params)%2 != 0 {
+		return "", errors.New("params must be pairs of name and value")
+	}
+
+	values := make(map[string]interface{}, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("param name %v must be a string", params[i])
+		}
+		values[key] = params[i+1]
+	}
+
+	b := strings.Builder{}
+	omitted := ""
+
+	for _, p := range rt.urlParts {
+		if omitted != "" {
+			if p.key != "" {
+				if _, ok := values[p.key]; ok {
+					return "", fmt.Errorf("param '%s' of route '%s' requires optional param '%s'", p.key, name, omitted)
+				}
+			}
+			continue
+		}
+
+		if p.key == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+
+		raw, ok := values[p.key]
+		delete(values, p.key)
+
+		v := fmt.Sprint(raw)
+		if _, isString := raw.(string); !isString && p.converter != nil && p.converter.Format != nil {
+			v = p.converter.Format(raw)
+		}
+
+		switch {
+		case !ok && p.optional:
+			omitted = p.key
+			// drop the slash before the omitted segment
+			s := strings.TrimSuffix(b.String(), "/")
+			if s == "" {
+				s = "/"
+			}
+			b.Reset()
+			b.WriteString(s)
+			continue
+		case !ok:
+			return "", fmt.Errorf("missing param '%s' of route '%s'", p.key, name)
+		case p.catchAll:
+			segments := strings.Split(strings.TrimPrefix(v, "/"), "/")
+			for i := range segments {
+				segments[i] = url.PathEscape(segments[i])
+			}
+			b.WriteString(strings.Join(segments, "/"))
+			continue
+		case p.regex != nil && !p.regex.MatchString(v):
+			return "", fmt.Errorf("param '%s' value '%s' doesn't match '%s' of route '%s'", p.key, v, p.pattern, name)
+		}
+
+		b.WriteString(url.PathEscape(v))
+	}
+
+	if len(values) > 0 {
+		query := url.Values{}
+		for k, v := range values {
+			query.Set(k, fmt.Sprint(v))
+		}
+		b.WriteByte('?')
+		b.WriteString(query.Encode())
+	}
+
+	return b.String(), nil
+}
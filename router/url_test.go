@@ -0,0 +1,108 @@
+package router
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/", text("home")).Name("home")
+	r.HandleFunc(fasthttp.MethodGet, "/articles/{id:[0-9]+}/{slug?}", text("article")).Name("article")
+	r.HandleFunc(fasthttp.MethodGet, "/archive/{day:date}/{id:int}", text("archive")).Name("archive")
+	r.HandleFunc(fasthttp.MethodGet, "/files/{path:*}", text("file")).Name("file")
+	r.HandleFunc(fasthttp.MethodGet, "/archive/{year}/{month?}/{day?}", text("archive")).Name("months")
+	r.Host("{tenant}.example.com").HandleFunc(fasthttp.MethodGet, "/dashboard", text("dashboard")).Name("dashboard")
+
+	tests := []struct {
+		name   string
+		params []interface{}
+		want   string
+		err    string // part of the expected error, empty if no error is expected
+	}{
+		{name: "home", want: "/"},
+		{name: "article", params: []interface{}{"id", 42, "slug", "hello world"}, want: "/articles/42/hello%20world"},
+		{name: "article", params: []interface{}{"id", 42}, want: "/articles/42"},
+		{name: "article", params: []interface{}{"id", 42, "page", 2}, want: "/articles/42?page=2"},
+		{name: "archive", params: []interface{}{"day", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "id", 7}, want: "/archive/2020-01-02/7"},
+		{name: "archive", params: []interface{}{"day", "2020-01-02", "id", "7"}, want: "/archive/2020-01-02/7"},
+		{name: "file", params: []interface{}{"path", "/docs/a b.txt"}, want: "/files/docs/a%20b.txt"},
+		{name: "months", params: []interface{}{"year", 2020}, want: "/archive/2020"},
+		{name: "dashboard", want: "/dashboard"},
+
+		{name: "missing", err: "no route named 'missing'"},
+		{name: "article", params: []interface{}{"id"}, err: "pairs of name and value"},
+		{name: "article", params: []interface{}{1, 42}, err: "must be a string"},
+		{name: "article", params: []interface{}{"slug", "hello"}, err: "missing param 'id'"},
+		{name: "file", err: "missing param 'path'"},
+		{name: "article", params: []interface{}{"id", "abc"}, err: "doesn't match '[0-9]+'"},
+		{name: "archive", params: []interface{}{"day", "today", "id", 7}, err: "param 'day' value 'today'"},
+		{name: "months", params: []interface{}{"year", 2020, "day", 2}, err: "requires optional param 'month'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.URL(tt.name, tt.params...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error of %v was %v; want %q", tt.params, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("url of %v was %q; want %q", tt.params, got, tt.want)
+			}
+		})
+	}
+}
+
+type linkView struct {
+	view.View
+}
+
+func (v *linkView) Get() {
+	u, err := v.URL("article", "id", v.Param("id"))
+	if err != nil {
+		v.Fail(err)
+		return
+	}
+	v.Ctx.SetBodyString(u)
+}
+
+func (v *linkView) Render() {}
+
+func TestViewURL(t *testing.T) {
+	r := New()
+	r.GET("/links/{id}", &linkView{})
+	r.HandleFunc(fasthttp.MethodGet, "/articles/{id:[0-9]+}", text("article")).Name("article")
+
+	if body := string(serve(r, "/links/42").Response.Body()); body != "/articles/42" {
+		t.Errorf("url was %q; want %q", body, "/articles/42")
+	}
+	if status := serve(r, "/links/abc").Response.StatusCode(); status != fasthttp.StatusInternalServerError {
+		t.Errorf("status of an invalid url was %d; want %d", status, fasthttp.StatusInternalServerError)
+	}
+
+	if _, err := (&view.View{}).URL("article"); err == nil {
+		t.Error("view without router generated a url")
+	}
+}
+
+func TestNamePanics(t *testing.T) {
+	r := New()
+	r.HandleFunc(fasthttp.MethodGet, "/a", text("a")).Name("a")
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("name registered twice didn't panic")
+		}
+	}()
+	r.HandleFunc(fasthttp.MethodGet, "/b", text("b")).Name("a")
+}
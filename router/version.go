@@ -0,0 +1,221 @@
+package router
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savsgio/gotils"
+	"github.com/valyala/fasthttp"
+)
+
+// Versioning selects the API version of requests, several strategies could be enabled.
+// The version of a request is taken from the path prefix if Prefix is set and the path has
+// it, otherwise from the Header and then from the Accept media type, else it's the Default.
+type Versioning struct {
+	// Prefix serves the routes of a version under the path prefix "/v<version>",
+	// e.g. "/v2/articles". The routes are served without the prefix as well for requests
+	// selecting the version by Vendor or Header and for the Default version.
+	Prefix bool
+
+	// Vendor selects the version by the Accept media type
+	// "application/vnd.<Vendor>.v<version>+json", e.g. "application/vnd.x.v2+json" for vendor "x"
+	Vendor string
+
+	// Header selects the version by the value of the header, e.g. "X-API-Version: 2"
+	Header string
+
+	// Default is the version of requests which don't select one by Prefix, Vendor or Header,
+	// they are not found if it's empty
+	Default string
+
+	// Deprecated versions, their responses have the Deprecation and Sunset headers
+	Deprecated map[string]Deprecation
+}
+
+// Deprecation describes a deprecated API version
+type Deprecation struct {
+	// Since is the time the version is deprecated since, the Deprecation header is
+	// "true" if it's zero
+	Since time.Time
+
+	// Sunset is the time the version will be removed, no Sunset header is set if it's zero
+	Sunset time.Time
+
+	// Link is the url documenting the deprecation, e.g. the migration guide
+	Link string
+}
+
+// Version returns a group whose routes serve the given version of the API, selected as
+// configured by the Versioning of the router, e.g. with Versioning{Header: "X-API-Version", Default: "1"}:
+//     r.Version("1").GET("/articles", &Articles{})   // no header or "X-API-Version: 1"
+//     r.Version("2").GET("/articles", &ArticlesV2{}) // "X-API-Version: 2"
+// The version of the matched route is stored under VersionParam. With Prefix, the route
+// returned by the group is the one of the prefixed path.
+// It panics if the router has no versioning strategy.
+func (r *Router) Version(version string) *Group {
+	return (&Group{router: r}).Version(version)
+}
+
+// Version returns a group whose routes serve the given version of the API, see Router.Version
+func (g *Group) Version(version string) *Group {
+	cfg := g.router.root().Versioning
+	switch {
+	case version == "":
+		panic("version must not be empty")
+	case !cfg.Prefix && cfg.Vendor == "" && cfg.Header == "":
+		panic("router has no versioning strategy, see Router.Versioning")
+	case g.version != "":
+		panic("group is already of version '" + g.version + "'")
+	}
+
+	vg := &Group{
+		router:   g.router,
+		prefix:   g.prefix,
+		matchers: g.matchers,
+		version:  version,
+		base:     g.base,
+	}
+	m := &versionMatcher{version: version, cfg: cfg}
+	switch {
+	case !cfg.Prefix:
+		vg.matchers = append(append([]Matcher(nil), g.matchers...), m)
+	case cfg.Vendor != "" || cfg.Header != "" || version == cfg.Default:
+		vg.prefix += "/v" + version
+		vg.unprefixed = m
+	default:
+		vg.prefix += "/v" + version
+	}
+
+	return vg
+}
+
+// versionMatcher matches requests selecting the version by header or media type
+type versionMatcher struct {
+	version string
+	cfg     Versioning
+}
+
+func (m *versionMatcher) Match(ctx *fasthttp.RequestCtx) bool {
+	if v := m.cfg.requestVersion(ctx); v != "" {
+		return v == m.version
+	}
+
+	return m.version == m.cfg.Default
+}
+
+func (m *versionMatcher) String() string {
+	if m.version == m.cfg.Default {
+		return "version(" + m.version + ", default)"
+	}
+
+	return "version(" + m.version + ")"
+}
+
+// requestVersion returns the version selected by the request header or media type,
+// empty if the request doesn't select one
+func (cfg Versioning) requestVersion(ctx *fasthttp.RequestCtx) string {
+	if cfg.Header != "" {
+		if v := ctx.Request.Header.Peek(cfg.Header); len(v) > 0 {
+			return strings.TrimPrefix(strings.TrimSpace(string(v)), "v")
+		}
+	}
+
+	if cfg.Vendor == "" {
+		return ""
+	}
+
+	prefix := "application/vnd." + strings.ToLower(cfg.Vendor) + ".v"
+	for _, accept := range strings.Split(gotils.B2S(ctx.Request.Header.Peek("Accept")), ",") {
+		mt, _, err := mime.ParseMediaType(accept)
+		if err != nil || !strings.HasPrefix(mt, prefix) {
+			continue
+		}
+
+		v := mt[len(prefix):]
+		if i := strings.IndexByte(v, '+'); i >= 0 {
+			v = v[:i]
+		}
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// setHeaders sets the deprecation headers of the version to the response
+func (cfg Versioning) setHeaders(ctx *fasthttp.RequestCtx, version string) {
+	d, ok := cfg.Deprecated[version]
+	if !ok {
+		return
+	}
+
+	if d.Since.IsZero() {
+		ctx.Response.Header.Set("Deprecation", "true")
+	} else {
+		ctx.Response.Header.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		ctx.Response.Header.Set("Sunset", string(fasthttp.AppendHTTPDate(nil, d.Sunset)))
+	}
+	if d.Link != "" {
+		ctx.Response.Header.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+	}
+}
+
+// Versions returns the API versions served for each path of the router and all its hosts,
+// paths are without the version prefix and paths of hosts are prefixed by the host pattern
+func (r *Router) Versions() map[string][]string {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	versions := make(map[string][]string)
+	r.collectVersions(versions)
+
+	for _, vs := range versions {
+		sort.Slice(vs, func(i, j int) bool {
+			return versionLess(vs[i], vs[j])
+		})
+	}
+
+	return versions
+}
+
+func (r *Router) collectVersions(versions map[string][]string) {
+	for _, rt := range r.routes {
+		if rt.version == "" {
+			continue
+		}
+
+		path := rt.router.host + rt.base
+		found := false
+		for _, v := range versions[path] {
+			if v == rt.version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			versions[path] = append(versions[path], rt.version)
+		}
+	}
+
+	for _, vh := range r.hosts {
+		vh.router.collectVersions(versions)
+	}
+}
+
+// versionLess compares versions numerically if both are numbers, e.g. "2" < "10"
+func versionLess(a, b string) bool {
+	ai, errA := strconv.Atoi(a)
+	bi, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return ai < bi
+	}
+
+	return a < b
+}
@@ -0,0 +1,198 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestVersioning(t *testing.T) {
+	type request struct {
+		path    string
+		headers []string
+		body    string // "" if the request is not found
+	}
+
+	tests := []struct {
+		name     string
+		cfg      Versioning
+		requests []request
+	}{
+		{
+			name: "header and vendor",
+			cfg:  Versioning{Header: "X-API-Version", Vendor: "x", Default: "1"},
+			requests: []request{
+				{"/articles", nil, "v1"},
+				{"/articles", []string{"X-API-Version", "2"}, "v2"},
+				{"/articles", []string{"X-API-Version", "v2"}, "v2"},
+				{"/articles", []string{"X-API-Version", "3"}, ""},
+				{"/articles", []string{"Accept", "text/html, application/vnd.x.v2+json"}, "v2"},
+				{"/articles", []string{"Accept", "application/vnd.y.v2+json"}, "v1"},
+				{"/articles", []string{"X-API-Version", "1", "Accept", "application/vnd.x.v2+json"}, "v1"},
+				{"/v2/articles", nil, ""},
+			},
+		},
+		{
+			name: "header without default",
+			cfg:  Versioning{Header: "X-API-Version"},
+			requests: []request{
+				{"/articles", nil, ""},
+				{"/articles", []string{"X-API-Version", "1"}, "v1"},
+			},
+		},
+		{
+			name: "prefix",
+			cfg:  Versioning{Prefix: true, Default: "1"},
+			requests: []request{
+				{"/v1/articles", nil, "v1"},
+				{"/v2/articles", nil, "v2"},
+				{"/articles", nil, "v1"},
+				{"/v3/articles", nil, ""},
+			},
+		},
+		{
+			name: "prefix without default",
+			cfg:  Versioning{Prefix: true},
+			requests: []request{
+				{"/v2/articles", nil, "v2"},
+				{"/articles", nil, ""},
+			},
+		},
+		{
+			name: "prefix and header",
+			cfg:  Versioning{Prefix: true, Header: "X-API-Version", Default: "1"},
+			requests: []request{
+				{"/v2/articles", nil, "v2"},
+				{"/articles", []string{"X-API-Version", "2"}, "v2"},
+				{"/articles", nil, "v1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.Versioning = tt.cfg
+			r.Version("1").HandleFunc(fasthttp.MethodGet, "/articles", text("v1"))
+			r.Version("2").HandleFunc(fasthttp.MethodGet, "/articles", text("v2"))
+
+			for _, req := range tt.requests {
+				ctx := serveHeaders(r, fasthttp.MethodGet, req.path, req.headers...)
+				body := string(ctx.Response.Body())
+				if ctx.Response.StatusCode() == fasthttp.StatusNotFound {
+					body = ""
+				}
+				if body != req.body {
+					t.Errorf("body of %s %v was %q; want %q", req.path, req.headers, body, req.body)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecatedVersion(t *testing.T) {
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New()
+	r.Versioning = Versioning{
+		Header:  "X-API-Version",
+		Default: "2",
+		Deprecated: map[string]Deprecation{
+			"1": {Since: time.Unix(1700000000, 0), Sunset: sunset, Link: "https://example.com/v2"},
+		},
+	}
+	r.Version("1").HandleFunc(fasthttp.MethodGet, "/articles", text("v1"))
+	r.Version("2").HandleFunc(fasthttp.MethodGet, "/articles", text("v2"))
+
+	tests := []struct {
+		version     string
+		deprecation string
+		sunset      string
+		link        string
+	}{
+		{"1", "@1700000000", "Tue, 01 Jan 2030 00:00:00 GMT", `<https://example.com/v2>; rel="deprecation"`},
+		{"2", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			ctx := serveHeaders(r, fasthttp.MethodGet, "/articles", "X-API-Version", tt.version)
+
+			if v := ctx.UserValue(VersionParam); v != tt.version {
+				t.Errorf("version was %v; want %s", v, tt.version)
+			}
+			for header, want := range map[string]string{"Deprecation": tt.deprecation, "Sunset": tt.sunset, "Link": tt.link} {
+				if got := string(ctx.Response.Header.Peek(header)); got != want {
+					t.Errorf("%s was %q; want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	r := New()
+	r.Versioning = Versioning{Prefix: true, Default: "1"}
+	for _, v := range []string{"10", "2", "1"} {
+		r.Version(v).HandleFunc(fasthttp.MethodGet, "/articles", text(v))
+	}
+	r.Version("2").HandleFunc(fasthttp.MethodGet, "/users", text("2"))
+	r.HandleFunc(fasthttp.MethodGet, "/health", text("ok"))
+
+	want := map[string][]string{
+		"/articles": {"1", "2", "10"},
+		"/users":    {"2"},
+	}
+	if got := r.Versions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("versions were %v; want %v", got, want)
+	}
+}
+
+func TestVersionPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Versioning
+		call func(r *Router)
+	}{
+		{"no strategy", Versioning{Default: "1"}, func(r *Router) { r.Version("1") }},
+		{"empty version", Versioning{Prefix: true}, func(r *Router) { r.Version("") }},
+		{"nested versions", Versioning{Prefix: true}, func(r *Router) { r.Version("1").Version("2") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("version didn't panic")
+				}
+			}()
+			r := New()
+			r.Versioning = tt.cfg
+			tt.call(r)
+		})
+	}
+}
+
+func TestVersionGroupChanges(t *testing.T) {
+	r := New()
+	r.Versioning = Versioning{Prefix: true, Default: "1"}
+	v1 := r.Version("1")
+	v1.HandleFunc(fasthttp.MethodGet, "/articles", text("v1"))
+
+	v1.Replace(fasthttp.MethodGet, "/articles", &HandlerView{handler: text("v1 new")})
+	for _, path := range []string{"/v1/articles", "/articles"} {
+		if ctx := serve(r, path); string(ctx.Response.Body()) != "v1 new" {
+			t.Errorf("body of %s was %q; want %q", path, ctx.Response.Body(), "v1 new")
+		}
+	}
+
+	if !v1.Remove(fasthttp.MethodGet, "/articles") {
+		t.Error("remove of a registered route returned false")
+	}
+	for _, path := range []string{"/v1/articles", "/articles"} {
+		if status := serve(r, path).Response.StatusCode(); status != fasthttp.StatusNotFound {
+			t.Errorf("status of %s was %d; want %d", path, status, fasthttp.StatusNotFound)
+		}
+	}
+}
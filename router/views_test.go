@@ -0,0 +1,60 @@
+package router
+
+import (
+	"io"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/xxxmailk/cera/view"
+)
+
+type itemView struct {
+	view.ApiView
+}
+
+func (v *itemView) Get() {
+	v.SetBody([]int{1, 2})
+	v.SetMeta("total", 2)
+}
+
+func TestViewOptions(t *testing.T) {
+	plain := New()
+	plain.GET("/items", &itemView{})
+
+	wrapped := New()
+	wrapped.Views = &view.Options{
+		Envelope: view.DefaultEnvelope,
+		JSONEncoder: func(w io.Writer, v interface{}) error {
+			if _, err := io.WriteString(w, "/* custom */"); err != nil {
+				return err
+			}
+			return view.StdJSONEncoder(w, v)
+		},
+	}
+	wrapped.GET("/items", &itemView{})
+
+	tests := []struct {
+		name string
+		r    *Router
+		path string
+		want string
+	}{
+		{"plain body", plain, "/items", `[1,2]`},
+		{"plain error", plain, "/missing", `{"status":404,"message":"Not Found"}`},
+		{"wrapped body", wrapped, "/items", `/* custom */{"data":[1,2],"meta":{"total":2}}`},
+		{"wrapped error", wrapped, "/missing", `/* custom */{"error":{"status":404,"message":"Not Found"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI(tt.path)
+			ctx.Request.Header.Set("Accept", "application/json")
+			tt.r.Handler(ctx)
+
+			if body := string(ctx.Response.Body()); body != tt.want {
+				t.Errorf("body was %s; want %s", body, tt.want)
+			}
+		})
+	}
+}
@@ -2,32 +2,105 @@ package view
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+
+	"github.com/valyala/bytebufferpool"
 )
 
+// JSONEncoder writes v encoded as JSON to w
+type JSONEncoder func(w io.Writer, v interface{}) error
+
+// StdJSONEncoder encodes by encoding/json, unlike json.Encoder it writes no trailing newline
+func StdJSONEncoder(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Envelope wraps JSON bodies into an object with the body, meta data and errors as members:
+//     {"data": [...], "meta": {"total": 42}}
+//     {"error": {"status": 404, "message": "Not Found"}}
+type Envelope struct {
+	DataKey  string
+	MetaKey  string
+	ErrorKey string
+}
+
+// DefaultEnvelope has the members "data", "meta" and "error"
+var DefaultEnvelope = &Envelope{DataKey: "data", MetaKey: "meta", ErrorKey: "error"}
+
+// wrap returns the body wrapped into the envelope, empty members are omitted
+func (e *Envelope) wrap(data interface{}, meta map[string]interface{}, err *HTTPError) map[string]interface{} {
+	m := make(map[string]interface{}, 2)
+	if err != nil {
+		m[e.ErrorKey] = err
+	} else {
+		m[e.DataKey] = data
+	}
+	if len(meta) > 0 {
+		m[e.MetaKey] = meta
+	}
+
+	return m
+}
+
 type ApiView struct {
 	View
+
+	// response body set by SetBody, Data is rendered if it's not set
+	body    interface{}
+	hasBody bool
+	meta    map[string]interface{}
 }
 
 func (r *ApiView) Render() {
 	r.JsonRender()
 }
 
+// SetBody sets the value rendered as JSON response body instead of Data,
+// e.g. a slice or a typed struct:
+//     r.SetBody([]Article{...})
+func (r *ApiView) SetBody(v interface{}) {
+	r.body = v
+	r.hasBody = true
+}
+
+// SetMeta sets a member of the meta data of the body, it's only rendered if the Options have an Envelope
+func (r *ApiView) SetMeta(key string, value interface{}) {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+}
+
 // render templates
 func (r *ApiView) JsonRender() {
 	ctx := r.GetCtx()
-	rs, err := json.Marshal(r.Data)
-	if err != nil {
-		_, err := ctx.Write([]byte(err.Error()))
-		log.Println("error: render data to json failed, ", err)
+
+	var body interface{} = r.Data
+	if r.hasBody {
+		body = r.body
+	}
+	opts := OptionsOf(ctx)
+	if e := opts.envelope(); e != nil {
+		body = e.wrap(body, r.meta, nil)
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := opts.EncodeJSON(buf, body); err != nil {
+		r.Fail(fmt.Errorf("render data to json failed, %s", err))
 		return
 	}
 	// set application/json header
-	ctx.Response.Header.Set("Content-type", "application/json")
-	_, err = ctx.Write(rs)
-	if err != nil {
+	ctx.SetContentTypeBytes(JSONContentType)
+	if _, err := ctx.Write(buf.B); err != nil {
 		log.Println("error: write json result to client failed,", err)
-		return
 	}
-	return
 }
@@ -0,0 +1,105 @@
+package view
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+type article struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestJsonRender(t *testing.T) {
+	failing := func(w io.Writer, v interface{}) error { return errors.New("encoder failed") }
+
+	tests := []struct {
+		name string
+		opts *Options
+		set  func(v *ApiView)
+		want string // expected body, empty if rendering fails
+	}{
+		{"data", nil, func(v *ApiView) { v.Data["hello"] = "world" }, `{"hello":"world"}`},
+		{"typed body", nil, func(v *ApiView) { v.SetBody([]article{{1, "a"}}) }, `[{"id":1,"title":"a"}]`},
+		{"null body", nil, func(v *ApiView) { v.SetBody(nil) }, `null`},
+		{"meta without envelope", nil, func(v *ApiView) { v.SetBody(1); v.SetMeta("total", 1) }, `1`},
+		{"envelope", &Options{Envelope: DefaultEnvelope}, func(v *ApiView) { v.SetBody(article{1, "a"}) }, `{"data":{"id":1,"title":"a"}}`},
+		{
+			"envelope with meta",
+			&Options{Envelope: &Envelope{DataKey: "items", MetaKey: "page", ErrorKey: "err"}},
+			func(v *ApiView) { v.SetBody([]int{1}); v.SetMeta("total", 1) },
+			`{"items":[1],"page":{"total":1}}`,
+		},
+		{"unencodable body", nil, func(v *ApiView) { v.SetBody(make(chan int)) }, ""},
+		{"failing encoder", &Options{JSONEncoder: failing}, func(v *ApiView) { v.SetBody(1) }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			if tt.opts != nil {
+				SetOptions(ctx, tt.opts)
+			}
+			v := &ApiView{}
+			v.Init()
+			v.SetCtx(ctx)
+			tt.set(v)
+			v.Render()
+
+			if tt.want == "" {
+				if v.Err() == nil {
+					t.Errorf("rendering didn't fail, body %s", ctx.Response.Body())
+				}
+				return
+			}
+			if v.Err() != nil {
+				t.Fatal(v.Err())
+			}
+			if body := string(ctx.Response.Body()); body != tt.want {
+				t.Errorf("body was %s; want %s", body, tt.want)
+			}
+			if ct := string(ctx.Response.Header.ContentType()); ct != string(JSONContentType) {
+				t.Errorf("content type was %q; want %q", ct, JSONContentType)
+			}
+		})
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		err  *HTTPError
+		want string
+	}{
+		{"plain", nil, NewHTTPError(fasthttp.StatusNotFound, ""), `{"status":404,"message":"Not Found"}`},
+		{"envelope", &Options{Envelope: DefaultEnvelope}, NewHTTPError(fasthttp.StatusNotFound, ""), `{"error":{"status":404,"message":"Not Found"}}`},
+		{
+			"unencodable details",
+			&Options{Envelope: DefaultEnvelope},
+			&HTTPError{Status: fasthttp.StatusBadRequest, Code: "invalid", Message: "invalid", Details: make(chan int)},
+			`{"error":{"status":400,"code":"invalid","message":"invalid"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			if tt.opts != nil {
+				SetOptions(ctx, tt.opts)
+			}
+			ctx.SetBodyString("partial body")
+			WriteJSONError(ctx, tt.err)
+
+			if status := ctx.Response.StatusCode(); status != tt.err.Status {
+				t.Errorf("status was %d; want %d", status, tt.err.Status)
+			}
+			if body := string(ctx.Response.Body()); body != tt.want {
+				t.Errorf("body was %s; want %s", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/valyala/bytebufferpool"
+	"github.com/valyala/fasthttp"
+)
+
+// HTTPError is an error answered with its status, views return it with View.Fail or
+// raise it with View.Abort. It's rendered by the error handler of the router.
+type HTTPError struct {
+	Type    string      `json:"type,omitempty"` // uri of the problem type, see Problem
+	Status  int         `json:"status"`
+	Code    string      `json:"code,omitempty"` // application specific error code, e.g. "article_not_found"
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+
+	// Err is the cause of the error, it's logged but not rendered
+	Err error `json:"-"`
+}
+
+// NewHTTPError returns an error with the status, the message is the status text if it's empty
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = fasthttp.StatusMessage(status)
+	}
+
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap returns the cause of the error
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// AsHTTPError returns err if it's or wraps an HTTPError, other errors are returned as
+// 500 Internal Server Error caused by err, their message is not exposed to clients
+func AsHTTPError(err error) *HTTPError {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e
+	}
+
+	e = NewHTTPError(fasthttp.StatusInternalServerError, "")
+	e.Err = err
+	return e
+}
+
+// AcceptsJSON reports whether the client prefers JSON to HTML, e.g. for errors of
+// requests which are not served by a view
+func AcceptsJSON(ctx *fasthttp.RequestCtx) bool {
+	accept := string(ctx.Request.Header.Peek("Accept"))
+	j := strings.Index(accept, "json")
+	h := strings.Index(accept, "text/html")
+
+	return j >= 0 && (h < 0 || j < h)
+}
+
+// WriteHTMLError replaces the response body by an html page of the error
+func WriteHTMLError(ctx *fasthttp.RequestCtx, e *HTTPError) {
+	ctx.Response.ResetBody()
+	ctx.SetStatusCode(e.Status)
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBodyString(`<html>
+<head>
+<title>` + html.EscapeString(fasthttp.StatusMessage(e.Status)) + `</title>
+</head>
+<body style="background:#000;text-align:center;">
+<span style="font-size:5em;color:#fff;"><b>` + fmt.Sprint(e.Status) + ` Sorry, ` + html.EscapeString(e.Message) + `! :) </b></span>
+</body>
+</html>
+`)
+}
+
+// WriteJSONError replaces the response body by the error encoded as JSON,
+// it's wrapped into the Envelope of the Options of the request if it's set
+func WriteJSONError(ctx *fasthttp.RequestCtx, e *HTTPError) {
+	opts := OptionsOf(ctx)
+	env := opts.envelope()
+	var body interface{} = e
+	if env != nil {
+		body = env.wrap(nil, nil, e)
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := opts.EncodeJSON(buf, body); err != nil {
+		// details can't be encoded
+		buf.Reset()
+		e = &HTTPError{Type: e.Type, Status: e.Status, Code: e.Code, Message: e.Message}
+		if env != nil {
+			_ = opts.EncodeJSON(buf, env.wrap(nil, nil, e))
+		} else {
+			_ = opts.EncodeJSON(buf, e)
+		}
+	}
+
+	ctx.Response.ResetBody()
+	ctx.SetStatusCode(e.Status)
+	ctx.SetContentTypeBytes(JSONContentType)
+	ctx.SetBody(buf.B)
+}
+
+// Fail stops the view with the error, the rest of the request method is up to the view:
+//     if article == nil {
+//         r.Fail(view.NewHTTPError(404, "article not found"))
+//         return
+//     }
+// Render is skipped and the error is rendered by the error handler of the router.
+// Errors which are no HTTPError are answered with 500 Internal Server Error.
+func (r *View) Fail(err error) {
+	r.err = err
+}
+
+// Abort stops the view by raising an HTTPError with the status, the message is the
+// status text if it's empty. The error is rendered by the error handler of the router.
+func (r *View) Abort(status int, message string) {
+	panic(NewHTTPError(status, message))
+}
+
+// Err returns the error the view failed with, see Fail
+func (r *View) Err() error {
+	return r.err
+}
@@ -0,0 +1,418 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/valyala/bytebufferpool"
+	"github.com/valyala/fasthttp"
+)
+
+// JSON-RPC 2.0 error codes, codes from -32000 to -32099 are reserved for server errors
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// RPCError is the error object of JSON-RPC responses, procedures return it to answer
+// with an application specific code:
+//     return nil, &view.RPCError{Code: 1001, Message: "insufficient funds", Data: balance}
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+var rpcMessages = map[int]string{
+	RPCParseError:     "Parse error",
+	RPCInvalidRequest: "Invalid Request",
+	RPCMethodNotFound: "Method not found",
+	RPCInvalidParams:  "Invalid params",
+	RPCInternalError:  "Internal error",
+}
+
+func newRPCError(code int, data interface{}) *RPCError {
+	return &RPCError{Code: code, Message: rpcMessages[code], Data: data}
+}
+
+// RPCMethod describes a registered procedure, it's listed by the "rpc.methods" procedure
+// and GET requests
+type RPCMethod struct {
+	Name   string `json:"name"`
+	Params string `json:"params,omitempty"` // go type of the params, empty if it takes none
+	Result string `json:"result,omitempty"` // go type of the result, empty if it returns none
+}
+
+// rpcMethodsName is the introspection procedure, names beginning with "rpc." are reserved
+const rpcMethodsName = "rpc.methods"
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	ctxType   = reflect.TypeOf((*fasthttp.RequestCtx)(nil))
+)
+
+type rpcProc struct {
+	fn     reflect.Value
+	ctx    bool         // the request ctx is the first argument
+	params reflect.Type // nil if the procedure takes no params
+	result bool
+}
+
+type rpcRegistry struct {
+	mu    sync.RWMutex
+	procs map[string]*rpcProc
+}
+
+// JSONRPCView serves JSON-RPC 2.0 calls of the procedures registered with Register,
+// single calls, batches and notifications are POSTed to the route of the view, GET
+// requests list the procedures:
+//     rpc := &view.JSONRPCView{}
+//     rpc.Register("math.sum", func(nums []int) (int, error) { ... })
+//     r.POST("/rpc", rpc)
+//     r.GET("/rpc", rpc)
+type JSONRPCView struct {
+	View
+
+	// MaxBatch is the maximum number of calls of a batch, 0 is unlimited
+	MaxBatch int
+
+	registry *rpcRegistry
+	response interface{}
+}
+
+// DeepCopy returns a view sharing the registered procedures for a request
+func (r *JSONRPCView) DeepCopy() interface{} {
+	return &JSONRPCView{MaxBatch: r.MaxBatch, registry: r.registry}
+}
+
+// Register registers fn as the procedure name, fn takes the request ctx and the params
+// optionally and returns the result optionally and an error:
+//     func(params P) (R, error)
+//     func(ctx *fasthttp.RequestCtx, params P) (R, error)
+//     func() error
+// Params are decoded into P from objects by name, params of a struct P are decoded from
+// arrays by position of the fields as well. Errors which are no RPCError are answered
+// with an internal error, their message is not exposed to clients. Register panics if
+// fn has another signature or the name is reserved.
+func (r *JSONRPCView) Register(name string, fn interface{}) {
+	switch {
+	case name == "":
+		panic("rpc method name must not be empty")
+	case strings.HasPrefix(name, "rpc."):
+		panic("rpc method names beginning with 'rpc.' are reserved in name '" + name + "'")
+	}
+
+	fv := reflect.ValueOf(fn)
+	switch {
+	case !fv.IsValid():
+		panic("rpc method '" + name + "' must not be nil")
+	case fv.Kind() != reflect.Func:
+		panic(fmt.Sprintf("rpc method '%s' must be a func, not %T", name, fn))
+	case fv.IsNil():
+		panic("rpc method '" + name + "' must not be a nil func")
+	}
+	ft := fv.Type()
+	if ft.IsVariadic() || ft.NumOut() < 1 || ft.NumOut() > 2 ||
+		!ft.Out(ft.NumOut()-1).Implements(errorType) {
+		panic(fmt.Sprintf("rpc method '%s' must be a func returning an optional result and an error, not %s", name, ft))
+	}
+
+	p := &rpcProc{fn: fv, result: ft.NumOut() == 2}
+	in := 0
+	if ft.NumIn() > 0 && ft.In(0) == ctxType {
+		p.ctx = true
+		in++
+	}
+	switch ft.NumIn() - in {
+	case 0:
+	case 1:
+		p.params = ft.In(in)
+	default:
+		panic(fmt.Sprintf("rpc method '%s' must take the ctx and params optionally, not %s", name, ft))
+	}
+
+	if r.registry == nil {
+		r.registry = &rpcRegistry{procs: make(map[string]*rpcProc)}
+	}
+	r.registry.mu.Lock()
+	r.registry.procs[name] = p
+	r.registry.mu.Unlock()
+}
+
+// Methods returns the registered procedures sorted by name
+func (r *JSONRPCView) Methods() []RPCMethod {
+	methods := []RPCMethod{{Name: rpcMethodsName, Result: "[]view.RPCMethod"}}
+	if r.registry == nil {
+		return methods
+	}
+
+	r.registry.mu.RLock()
+	for name, p := range r.registry.procs {
+		m := RPCMethod{Name: name}
+		if p.params != nil {
+			m.Params = p.params.String()
+		}
+		if p.result {
+			m.Result = p.fn.Type().Out(0).String()
+		}
+		methods = append(methods, m)
+	}
+	r.registry.mu.RUnlock()
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	return methods
+}
+
+// Get lists the registered procedures
+func (r *JSONRPCView) Get() {
+	r.response = r.Methods()
+}
+
+// Post calls the procedures of the call or the batch in the request body, requests
+// consisting of notifications only are answered with 204 No Content
+func (r *JSONRPCView) Post() {
+	body := bytes.TrimSpace(r.GetCtx().PostBody())
+
+	if len(body) == 0 || body[0] != '[' {
+		var raw json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			r.response = &rpcResponse{Error: newRPCError(RPCParseError, nil)}
+			return
+		}
+		if resp := r.call(raw); resp != nil {
+			r.response = resp
+		}
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		r.response = &rpcResponse{Error: newRPCError(RPCParseError, nil)}
+		return
+	}
+	switch {
+	case len(batch) == 0:
+		r.response = &rpcResponse{Error: newRPCError(RPCInvalidRequest, "empty batch")}
+		return
+	case r.MaxBatch > 0 && len(batch) > r.MaxBatch:
+		r.response = &rpcResponse{Error: newRPCError(RPCInvalidRequest, fmt.Sprintf("batch exceeds %d calls", r.MaxBatch))}
+		return
+	}
+
+	responses := make([]*rpcResponse, 0, len(batch))
+	for _, raw := range batch {
+		if resp := r.call(raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) > 0 {
+		r.response = responses
+	}
+}
+
+func (r *JSONRPCView) Render() {
+	r.JsonRender()
+}
+
+// JsonRender writes the response, nothing is written to notifications
+func (r *JSONRPCView) JsonRender() {
+	ctx := r.GetCtx()
+	if r.response == nil {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+		return
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := OptionsOf(ctx).EncodeJSON(buf, r.response); err != nil {
+		r.Fail(fmt.Errorf("render rpc response to json failed, %s", err))
+		return
+	}
+	ctx.SetContentTypeBytes(JSONContentType)
+	ctx.SetBody(buf.B)
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// MarshalJSON sets the version and the null id of responses to invalid requests
+func (resp *rpcResponse) MarshalJSON() ([]byte, error) {
+	type response rpcResponse
+	v := response(*resp)
+	v.JSONRPC = "2.0"
+	if len(v.ID) == 0 {
+		v.ID = json.RawMessage("null")
+	}
+	return json.Marshal(&v)
+}
+
+// call calls the procedure of the request, the response is nil for notifications
+func (r *JSONRPCView) call(raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return &rpcResponse{Error: newRPCError(RPCInvalidRequest, nil), ID: validID(req.ID)}
+	}
+	if req.ID != nil && validID(req.ID) == nil {
+		return &rpcResponse{Error: newRPCError(RPCInvalidRequest, "id must be a string, a number or null")}
+	}
+	if len(req.Params) > 0 && req.Params[0] != '{' && req.Params[0] != '[' {
+		return &rpcResponse{Error: newRPCError(RPCInvalidParams, "params must be an object or an array"), ID: req.ID}
+	}
+
+	result, rpcErr := r.invoke(req.Method, req.Params)
+	if req.ID == nil {
+		// notification
+		return nil
+	}
+	return &rpcResponse{Result: result, Error: rpcErr, ID: req.ID}
+}
+
+// validID returns the id of a request if it's a string, a number or null
+func validID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return nil
+	}
+	switch c := id[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9', bytes.Equal(id, []byte("null")):
+		return id
+	}
+	return nil
+}
+
+// invoke calls the procedure with the params and returns its encoded result
+func (r *JSONRPCView) invoke(method string, params json.RawMessage) (result json.RawMessage, rpcErr *RPCError) {
+	if method == rpcMethodsName {
+		return r.encodeResult(r.Methods())
+	}
+
+	var p *rpcProc
+	if r.registry != nil {
+		r.registry.mu.RLock()
+		p = r.registry.procs[method]
+		r.registry.mu.RUnlock()
+	}
+	if p == nil {
+		return nil, newRPCError(RPCMethodNotFound, method)
+	}
+
+	var args []reflect.Value
+	if p.ctx {
+		args = append(args, reflect.ValueOf(r.GetCtx()))
+	}
+	if p.params != nil {
+		v, err := decodeParams(params, p.params)
+		if err != nil {
+			return nil, newRPCError(RPCInvalidParams, err.Error())
+		}
+		args = append(args, v)
+	}
+
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			r.logError("panic in rpc method '%s': %v\n%s", method, rcv, debug.Stack())
+			result, rpcErr = nil, newRPCError(RPCInternalError, nil)
+		}
+	}()
+	out := p.fn.Call(args)
+
+	if err, _ := out[len(out)-1].Interface().(error); err != nil {
+		var e *RPCError
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		r.logError("rpc method '%s' failed, %s", method, err)
+		return nil, newRPCError(RPCInternalError, nil)
+	}
+	if !p.result {
+		return json.RawMessage("null"), nil
+	}
+	return r.encodeResult(out[0].Interface())
+}
+
+func (r *JSONRPCView) encodeResult(v interface{}) (json.RawMessage, *RPCError) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := OptionsOf(r.GetCtx()).EncodeJSON(buf, v); err != nil {
+		r.logError("render rpc result to json failed, %s", err)
+		return nil, newRPCError(RPCInternalError, nil)
+	}
+	return append(json.RawMessage(nil), buf.B...), nil
+}
+
+func (r *JSONRPCView) logError(format string, args ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.Errorf(format, args...)
+	}
+}
+
+// decodeParams decodes the params into a value of type t, params of a struct are
+// decoded from an array by position of the exported fields
+func decodeParams(params json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	base := t
+	if t.Kind() == reflect.Ptr {
+		base = t.Elem()
+	}
+	v := reflect.New(base)
+
+	switch {
+	case len(params) == 0:
+	case params[0] == '[' && base.Kind() == reflect.Struct:
+		var items []json.RawMessage
+		if err := json.Unmarshal(params, &items); err != nil {
+			return reflect.Value{}, err
+		}
+		var fields []int
+		for i := 0; i < base.NumField(); i++ {
+			if base.Field(i).PkgPath == "" {
+				fields = append(fields, i)
+			}
+		}
+		if len(items) > len(fields) {
+			return reflect.Value{}, fmt.Errorf("at most %d params expected, got %d", len(fields), len(items))
+		}
+		for i, item := range items {
+			f := v.Elem().Field(fields[i])
+			if err := json.Unmarshal(item, f.Addr().Interface()); err != nil {
+				return reflect.Value{}, fmt.Errorf("param %d: %s", i, err)
+			}
+		}
+	default:
+		if err := json.Unmarshal(params, v.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		return v, nil
+	}
+	return v.Elem(), nil
+}
@@ -0,0 +1,208 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// postRPC serves a POST request of the body by a copy of rpc like the router does
+func postRPC(t *testing.T, rpc *JSONRPCView, body string) *fasthttp.RequestCtx {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetBodyString(body)
+
+	v := rpc.DeepCopy().(*JSONRPCView)
+	v.SetCtx(ctx)
+	if err := Switcher(v); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestJSONRPC(t *testing.T) {
+	type pair struct {
+		A int
+		B int
+	}
+	rpc := &JSONRPCView{MaxBatch: 3}
+	rpc.Register("sum", func(p pair) (int, error) { return p.A + p.B, nil })
+	rpc.Register("fail", func() error { return errors.New("database is down") })
+	rpc.Register("deny", func() error { return &RPCError{Code: 1001, Message: "denied"} })
+	rpc.Register("panic", func() error { panic("boom") })
+
+	tests := []struct {
+		name string
+		body string
+		want string // expected response, empty if the request is answered with 204
+	}{
+		{
+			name: "call by name",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": {"A": 1, "B": 2}, "id": 1}`,
+			want: `{"jsonrpc": "2.0", "result": 3, "id": 1}`,
+		},
+		{
+			name: "call by position",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": "a"}`,
+			want: `{"jsonrpc": "2.0", "result": 3, "id": "a"}`,
+		},
+		{
+			name: "null id",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": null}`,
+			want: `{"jsonrpc": "2.0", "result": 3, "id": null}`,
+		},
+		{
+			name: "notification",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2]}`,
+		},
+		{
+			name: "failing notification",
+			body: `{"jsonrpc": "2.0", "method": "fail"}`,
+		},
+		{
+			name: "batch",
+			body: `[
+				{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": 1},
+				{"jsonrpc": "2.0", "method": "sum", "params": [3, 4]},
+				{"jsonrpc": "2.0", "method": "missing", "id": 2}
+			]`,
+			want: `[
+				{"jsonrpc": "2.0", "result": 3, "id": 1},
+				{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found", "data": "missing"}, "id": 2}
+			]`,
+		},
+		{
+			name: "batch of notifications",
+			body: `[{"jsonrpc": "2.0", "method": "sum", "params": [1, 2]}, {"jsonrpc": "2.0", "method": "fail"}]`,
+		},
+		{
+			name: "parse error",
+			body: `{"jsonrpc": "2.0", "method": "sum"`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+		},
+		{
+			name: "batch parse error",
+			body: `[{"jsonrpc": "2.0", "method": "sum"}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+		},
+		{
+			name: "invalid request",
+			body: `{"jsonrpc": "1.0", "method": "sum", "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+		},
+		{
+			name: "invalid id",
+			body: `{"jsonrpc": "2.0", "method": "sum", "id": {}}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request", "data": "id must be a string, a number or null"}, "id": null}`,
+		},
+		{
+			name: "invalid batch item",
+			body: `[1]`,
+			want: `[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}]`,
+		},
+		{
+			name: "empty batch",
+			body: `[]`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request", "data": "empty batch"}, "id": null}`,
+		},
+		{
+			name: "batch limit",
+			body: `[1, 2, 3, 4]`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request", "data": "batch exceeds 3 calls"}, "id": null}`,
+		},
+		{
+			name: "method not found",
+			body: `{"jsonrpc": "2.0", "method": "missing", "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found", "data": "missing"}, "id": 1}`,
+		},
+		{
+			name: "invalid params",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": 1, "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params", "data": "params must be an object or an array"}, "id": 1}`,
+		},
+		{
+			name: "too many params",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2, 3], "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params", "data": "at most 2 params expected, got 3"}, "id": 1}`,
+		},
+		{
+			name: "internal error",
+			body: `{"jsonrpc": "2.0", "method": "fail", "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal error"}, "id": 1}`,
+		},
+		{
+			name: "panic",
+			body: `{"jsonrpc": "2.0", "method": "panic", "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal error"}, "id": 1}`,
+		},
+		{
+			name: "application error",
+			body: `{"jsonrpc": "2.0", "method": "deny", "id": 1}`,
+			want: `{"jsonrpc": "2.0", "error": {"code": 1001, "message": "denied"}, "id": 1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := postRPC(t, rpc, tt.body)
+
+			if tt.want == "" {
+				if status := ctx.Response.StatusCode(); status != fasthttp.StatusNoContent {
+					t.Errorf("status was %d; want %d", status, fasthttp.StatusNoContent)
+				}
+				if len(ctx.Response.Body()) != 0 {
+					t.Errorf("body was %q; want none", ctx.Response.Body())
+				}
+				return
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(ctx.Response.Body(), &got); err != nil {
+				t.Fatalf("body %q isn't json, %s", ctx.Response.Body(), err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("response was %s; want %s", ctx.Response.Body(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRPCRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		fn     interface{}
+	}{
+		{"empty name", "", func() error { return nil }},
+		{"reserved name", "rpc.echo", func() error { return nil }},
+		{"nil", "echo", nil},
+		{"nil func", "echo", (func() error)(nil)},
+		{"not a func", "echo", 1},
+		{"no error", "echo", func(s string) string { return s }},
+		{"too many params", "echo", func(a, b string) error { return nil }},
+		{"variadic", "echo", func(s ...string) error { return nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				rcv := recover()
+				if rcv == nil {
+					t.Fatalf("registering %s didn't panic", tt.method)
+				}
+				if msg, ok := rcv.(string); !ok || tt.method != "" && !strings.Contains(msg, "'"+tt.method+"'") {
+					t.Errorf("panic %v doesn't name the method %q", rcv, tt.method)
+				}
+			}()
+			(&JSONRPCView{}).Register(tt.method, tt.fn)
+		})
+	}
+}
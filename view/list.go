@@ -0,0 +1,312 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ListOptions are the limits of the query parameters of a list endpoint, see ParseListQuery
+type ListOptions struct {
+	DefaultSize int // page size if size is not requested, 20 if it's 0
+	MaxSize     int // maximum page size, 100 if it's 0
+	MaxOffset   int // maximum number of skipped items of requested pages, 100000 if it's 0
+
+	// Sorts are the fields the list could be sorted by, sorting is refused if it's empty
+	Sorts []string
+	// DefaultSort is the sort of the list if sort is not requested, e.g. "-created"
+	DefaultSort string
+	// Filters are the fields the list could be filtered by. Parameters of these fields and
+	// parameters with an operator, e.g. "age[gte]", are filters, the latter are refused for
+	// other fields. Other parameters, e.g. "q" or "fields", are ignored.
+	Filters []string
+}
+
+// SortField is a field of the requested sort, "-name" sorts by name descending
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// filter operators, the value of FilterIn is a comma separated list
+const (
+	FilterEq   = "eq"
+	FilterNe   = "ne"
+	FilterLt   = "lt"
+	FilterLte  = "lte"
+	FilterGt   = "gt"
+	FilterGte  = "gte"
+	FilterLike = "like"
+	FilterIn   = "in"
+)
+
+var filterOps = map[string]bool{
+	FilterEq: true, FilterNe: true, FilterLt: true, FilterLte: true,
+	FilterGt: true, FilterGte: true, FilterLike: true, FilterIn: true,
+}
+
+// Filter is a requested filter, "status=active" is status eq active, "age[gte]=18" is age gte 18
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Values returns the comma separated values of the filter, e.g. of FilterIn
+func (f Filter) Values() []string {
+	return strings.Split(f.Value, ",")
+}
+
+// ListQuery is the parsed query of a list request:
+//     GET /articles?page=2&size=10&sort=-created,title&status=published&views[gte]=100
+//     GET /articles?offset=10&size=10
+//     GET /articles?cursor=eyJpZCI6NDJ9&size=10
+type ListQuery struct {
+	Page   int    // 1-based page, derived from offset if offset is requested
+	Size   int    // page size
+	Offset int    // number of skipped items, derived from page if page is requested
+	Cursor string // opaque cursor of cursor pagination, empty on the first page
+
+	Sort    []SortField
+	Filters []Filter
+
+	byOffset bool
+}
+
+// Filter returns the first filter of the field, ok is false if the field is not filtered
+func (q *ListQuery) Filter(field string) (f Filter, ok bool) {
+	for _, f := range q.Filters {
+		if f.Field == field {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// ParseListQuery parses the page, offset, cursor, size and sort parameters and the filters
+// of the request. Sizes above the maximum are limited to it, pages beyond the maximum offset
+// and unknown sort and filter fields are refused. The returned error is a 400 Bad Request listing the invalid parameters in
+// the "invalid-params" member of its details.
+func ParseListQuery(ctx *fasthttp.RequestCtx, opts ListOptions) (*ListQuery, error) {
+	if opts.DefaultSize <= 0 {
+		opts.DefaultSize = 20
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 100
+	}
+	if opts.DefaultSize > opts.MaxSize {
+		opts.DefaultSize = opts.MaxSize
+	}
+	if opts.MaxOffset <= 0 {
+		opts.MaxOffset = 100000
+	}
+
+	args := ctx.QueryArgs()
+	q := &ListQuery{Page: 1, Size: opts.DefaultSize}
+	var invalid []InvalidParam
+	fail := func(name, reason string) {
+		invalid = append(invalid, InvalidParam{Name: name, Reason: reason})
+	}
+
+	if args.Has("size") {
+		size, err := strconv.Atoi(string(args.Peek("size")))
+		switch {
+		case err != nil || size < 1:
+			fail("size", "must be a positive integer")
+		case size > opts.MaxSize:
+			q.Size = opts.MaxSize
+		default:
+			q.Size = size
+		}
+	}
+
+	if args.Has("page") && args.Has("offset") {
+		fail("offset", "must not be combined with page")
+	}
+	if args.Has("cursor") && (args.Has("page") || args.Has("offset")) {
+		fail("cursor", "must not be combined with page or offset")
+	}
+	if args.Has("page") {
+		page, err := strconv.Atoi(string(args.Peek("page")))
+		if err != nil || page < 1 {
+			fail("page", "must be a positive integer")
+		} else {
+			q.Page = page
+		}
+	}
+	if args.Has("offset") {
+		offset, err := strconv.Atoi(string(args.Peek("offset")))
+		if err != nil || offset < 0 {
+			fail("offset", "must be a non-negative integer")
+		} else {
+			q.Offset = offset
+			q.byOffset = true
+		}
+	}
+	q.Cursor = string(args.Peek("cursor"))
+
+	// checked by division as the offset of huge pages overflows
+	switch {
+	case q.byOffset && q.Offset > opts.MaxOffset:
+		fail("offset", fmt.Sprintf("must not be greater than %d", opts.MaxOffset))
+	case !q.byOffset && q.Page-1 > opts.MaxOffset/q.Size:
+		fail("page", fmt.Sprintf("must not be greater than %d", opts.MaxOffset/q.Size+1))
+	case q.byOffset:
+		q.Page = q.Offset/q.Size + 1
+	default:
+		q.Offset = (q.Page - 1) * q.Size
+	}
+
+	sorts := opts.DefaultSort
+	if args.Has("sort") {
+		sorts = string(args.Peek("sort"))
+	}
+	for _, s := range strings.Split(sorts, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		f := SortField{Field: strings.TrimLeft(s, "+-"), Desc: s[0] == '-'}
+		if !contains(opts.Sorts, f.Field) {
+			fail("sort", fmt.Sprintf("can't sort by '%s'", f.Field))
+			continue
+		}
+		q.Sort = append(q.Sort, f)
+	}
+
+	args.VisitAll(func(key, value []byte) {
+		name := string(key)
+		switch name {
+		case "page", "size", "offset", "cursor", "sort":
+			return
+		}
+
+		f := Filter{Field: name, Op: FilterEq, Value: string(value)}
+		if i := strings.IndexByte(name, '['); i > 0 && strings.HasSuffix(name, "]") {
+			f.Field, f.Op = name[:i], name[i+1:len(name)-1]
+		} else if !contains(opts.Filters, name) {
+			// not a filter, e.g. a search or language parameter
+			return
+		}
+		switch {
+		case !contains(opts.Filters, f.Field):
+			fail(name, fmt.Sprintf("can't filter by '%s'", f.Field))
+		case !filterOps[f.Op]:
+			fail(name, fmt.Sprintf("unknown filter operator '%s'", f.Op))
+		default:
+			q.Filters = append(q.Filters, f)
+		}
+	})
+
+	if len(invalid) > 0 {
+		sort.SliceStable(invalid, func(i, j int) bool {
+			return invalid[i].Name < invalid[j].Name
+		})
+		e := NewHTTPError(fasthttp.StatusBadRequest, "the list parameters are invalid")
+		e.Details = map[string]interface{}{"invalid-params": invalid}
+		return nil, e
+	}
+
+	return q, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ListQuery parses the list parameters of the request, see ParseListQuery:
+//     q, err := r.ListQuery(view.ListOptions{Sorts: []string{"created", "title"}, Filters: []string{"status"}})
+//     if err != nil {
+//         r.Fail(err)
+//         return
+//     }
+func (r *ApiView) ListQuery(opts ListOptions) (*ListQuery, error) {
+	return ParseListQuery(r.GetCtx(), opts)
+}
+
+// Paginate sets the pagination meta data of the page of the list with total items and the
+// X-Total-Count header, the Link header refers to the first, previous, next and last page.
+// Links keep the other query parameters of the request and use offset if it was requested.
+// The meta data is only rendered if the Options have an Envelope, the headers are set in any case.
+func (r *ApiView) Paginate(q *ListQuery, total int) {
+	ctx := r.GetCtx()
+	pages := (total + q.Size - 1) / q.Size
+
+	r.SetMeta("page", q.Page)
+	r.SetMeta("size", q.Size)
+	r.SetMeta("total", total)
+	r.SetMeta("pages", pages)
+	ctx.Response.Header.Set("X-Total-Count", strconv.Itoa(total))
+
+	link := func(offset int) string {
+		if q.byOffset {
+			return pageLink(ctx, "offset", offset, q.Size)
+		}
+		return pageLink(ctx, "page", offset/q.Size+1, q.Size)
+	}
+
+	last := 0
+	if pages > 0 {
+		last = (pages - 1) * q.Size
+	}
+	links := []string{linkValue(link(0), "first")}
+	if q.Offset > 0 {
+		prev := q.Offset - q.Size
+		if prev > last {
+			prev = last
+		} else if prev < 0 {
+			prev = 0
+		}
+		links = append(links, linkValue(link(prev), "prev"))
+	}
+	if q.Offset+q.Size < total {
+		links = append(links, linkValue(link(q.Offset+q.Size), "next"))
+	}
+	if pages > 0 {
+		links = append(links, linkValue(link(last), "last"))
+	}
+	ctx.Response.Header.Set("Link", strings.Join(links, ", "))
+}
+
+// PaginateCursor sets the cursor of the next page as "next_cursor" meta data and the
+// Link header referring to it, next is empty on the last page. The meta data is only
+// rendered if the Options have an Envelope, the Link header is set in any case.
+func (r *ApiView) PaginateCursor(q *ListQuery, next string) {
+	ctx := r.GetCtx()
+
+	r.SetMeta("size", q.Size)
+	if next == "" {
+		return
+	}
+	r.SetMeta("next_cursor", next)
+
+	uri := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(uri)
+	ctx.URI().CopyTo(uri)
+	uri.QueryArgs().Set("cursor", next)
+	uri.QueryArgs().SetUint("size", q.Size)
+	ctx.Response.Header.Set("Link", linkValue(string(uri.RequestURI()), "next"))
+}
+
+// pageLink returns the request uri with the page or offset and size parameters set
+func pageLink(ctx *fasthttp.RequestCtx, param string, value, size int) string {
+	uri := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(uri)
+	ctx.URI().CopyTo(uri)
+	uri.QueryArgs().SetUint(param, value)
+	uri.QueryArgs().SetUint("size", size)
+
+	return string(uri.RequestURI())
+}
+
+// linkValue returns a link of the Link header, see RFC 8288
+func linkValue(uri, rel string) string {
+	return "<" + uri + `>; rel="` + rel + `"`
+}
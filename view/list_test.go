@@ -0,0 +1,172 @@
+package view
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// listCtx returns a ctx of a GET request of the uri
+func listCtx(uri string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(uri)
+	return ctx
+}
+
+func TestParseListQuery(t *testing.T) {
+	opts := ListOptions{
+		DefaultSize: 10,
+		MaxSize:     50,
+		MaxOffset:   1000,
+		Sorts:       []string{"created", "title"},
+		DefaultSort: "-created",
+		Filters:     []string{"status", "views"},
+	}
+
+	tests := []struct {
+		uri  string
+		want ListQuery
+	}{
+		{"/articles", ListQuery{Page: 1, Size: 10, Sort: []SortField{{"created", true}}}},
+		{"/articles?page=3&size=20", ListQuery{Page: 3, Size: 20, Offset: 40, Sort: []SortField{{"created", true}}}},
+		{"/articles?offset=25&size=10", ListQuery{Page: 3, Size: 10, Offset: 25, Sort: []SortField{{"created", true}}, byOffset: true}},
+		{"/articles?size=500", ListQuery{Page: 1, Size: 50, Sort: []SortField{{"created", true}}}},
+		{"/articles?cursor=abc", ListQuery{Page: 1, Size: 10, Cursor: "abc", Sort: []SortField{{"created", true}}}},
+		{"/articles?sort=title,-created", ListQuery{Page: 1, Size: 10, Sort: []SortField{{"title", false}, {"created", true}}}},
+		{"/articles?sort=", ListQuery{Page: 1, Size: 10}},
+		{
+			"/articles?status=published&views[gte]=100&status[in]=a,b&q=go",
+			ListQuery{Page: 1, Size: 10, Sort: []SortField{{"created", true}}, Filters: []Filter{
+				{"status", FilterEq, "published"}, {"views", FilterGte, "100"}, {"status", FilterIn, "a,b"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			q, err := ParseListQuery(listCtx(tt.uri), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*q, tt.want) {
+				t.Errorf("query was %+v; want %+v", *q, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListQueryErrors(t *testing.T) {
+	opts := ListOptions{MaxOffset: 100, Sorts: []string{"title"}, Filters: []string{"status"}}
+
+	tests := []struct {
+		uri     string
+		invalid []InvalidParam
+	}{
+		{"/?size=0", []InvalidParam{{"size", "must be a positive integer"}}},
+		{"/?page=x", []InvalidParam{{"page", "must be a positive integer"}}},
+		{"/?offset=-1", []InvalidParam{{"offset", "must be a non-negative integer"}}},
+		{"/?page=2&offset=20", []InvalidParam{{"offset", "must not be combined with page"}}},
+		{"/?cursor=abc&page=2", []InvalidParam{{"cursor", "must not be combined with page or offset"}}},
+		{"/?offset=101", []InvalidParam{{"offset", "must not be greater than 100"}}},
+		{"/?page=7", []InvalidParam{{"page", "must not be greater than 6"}}},
+		{"/?page=9223372036854775807", []InvalidParam{{"page", "must not be greater than 6"}}},
+		{"/?sort=-created", []InvalidParam{{"sort", "can't sort by 'created'"}}},
+		{"/?author[eq]=bob", []InvalidParam{{"author[eq]", "can't filter by 'author'"}}},
+		{"/?status[regex]=a", []InvalidParam{{"status[regex]", "unknown filter operator 'regex'"}}},
+		{"/?size=0&sort=x", []InvalidParam{{"size", "must be a positive integer"}, {"sort", "can't sort by 'x'"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			_, err := ParseListQuery(listCtx(tt.uri), opts)
+			e, ok := err.(*HTTPError)
+			if !ok || e.Status != fasthttp.StatusBadRequest {
+				t.Fatalf("error was %v; want 400 Bad Request", err)
+			}
+			invalid := e.Details.(map[string]interface{})["invalid-params"]
+			if !reflect.DeepEqual(invalid, tt.invalid) {
+				t.Errorf("invalid params were %v; want %v", invalid, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		uri   string
+		total int
+		link  string
+		meta  string
+	}{
+		{
+			"/articles?page=2&size=10&q=go", 35,
+			`</articles?page=1&size=10&q=go>; rel="first", </articles?page=1&size=10&q=go>; rel="prev", </articles?page=3&size=10&q=go>; rel="next", </articles?page=4&size=10&q=go>; rel="last"`,
+			`{"page":2,"pages":4,"size":10,"total":35}`,
+		},
+		{
+			"/articles?offset=5&size=10", 12,
+			`</articles?offset=0&size=10>; rel="first", </articles?offset=0&size=10>; rel="prev", </articles?offset=10&size=10>; rel="last"`,
+			`{"page":1,"pages":2,"size":10,"total":12}`,
+		},
+		{
+			"/articles", 0,
+			`</articles?page=1&size=20>; rel="first"`,
+			`{"page":1,"pages":0,"size":20,"total":0}`,
+		},
+		{
+			"/articles?page=9&size=10", 35,
+			`</articles?page=1&size=10>; rel="first", </articles?page=4&size=10>; rel="prev", </articles?page=4&size=10>; rel="last"`,
+			`{"page":9,"pages":4,"size":10,"total":35}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			ctx := listCtx(tt.uri)
+			v := &ApiView{}
+			v.SetCtx(ctx)
+			q, err := v.ListQuery(ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.Paginate(q, tt.total)
+
+			if link := string(ctx.Response.Header.Peek("Link")); link != tt.link {
+				t.Errorf("link was\n%s\nwant\n%s", link, tt.link)
+			}
+			if meta, _ := json.Marshal(v.meta); string(meta) != tt.meta {
+				t.Errorf("meta was %s; want %s", meta, tt.meta)
+			}
+		})
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	ctx := listCtx("/articles?cursor=a&size=5")
+	v := &ApiView{}
+	v.SetCtx(ctx)
+	q, err := v.ListQuery(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.PaginateCursor(q, "b")
+	if link, want := string(ctx.Response.Header.Peek("Link")), `</articles?cursor=b&size=5>; rel="next"`; link != want {
+		t.Errorf("link was %s; want %s", link, want)
+	}
+	if next := v.meta["next_cursor"]; next != "b" {
+		t.Errorf("next cursor was %v; want b", next)
+	}
+
+	ctx.Response.Reset()
+	v.meta = nil
+	v.PaginateCursor(q, "")
+	if link := ctx.Response.Header.Peek("Link"); len(link) > 0 {
+		t.Errorf("link of the last page was %s; want none", link)
+	}
+	if _, ok := v.meta["next_cursor"]; ok {
+		t.Error("next cursor of the last page was set")
+	}
+}
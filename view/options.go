@@ -0,0 +1,92 @@
+package view
+
+import (
+	"html/template"
+	"io"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// optionsKey is the user value under which the router stores the options of its views
+const optionsKey = "__cera.viewOptions__"
+
+// Options are the rendering options of the views of a router, set them as Router.Views.
+// The router passes them to its views and error handlers with the request, requests
+// which don't pass a router are rendered with the zero Options.
+// Options must not be copied or changed after the router serves requests.
+type Options struct {
+	// JSONEncoder encodes the bodies of api views and JSON errors, StdJSONEncoder if it's
+	// nil. It could be a faster encoder compatible with encoding/json, e.g. jsoniter:
+	//     r.Views = &view.Options{JSONEncoder: func(w io.Writer, v interface{}) error {
+	//         b, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(v)
+	//         if err == nil {
+	//             _, err = w.Write(b)
+	//         }
+	//         return err
+	//     }}
+	// Encoders write the JSON value only, results of JSON-RPC calls are embedded as they are.
+	JSONEncoder JSONEncoder
+
+	// Envelope wraps the bodies of api views and JSON errors if it's set, e.g. to
+	// DefaultEnvelope. Bodies are rendered as they are if it's nil.
+	Envelope *Envelope
+
+	// TemplateCache parses the templates once, they are parsed for each request if it's
+	// disabled so changes are served without restarting
+	TemplateCache bool
+
+	templatesMu sync.Mutex
+	templates   *template.Template
+}
+
+// SetOptions passes the options to the views and error handlers of the request
+func SetOptions(ctx *fasthttp.RequestCtx, o *Options) {
+	ctx.SetUserValue(optionsKey, o)
+}
+
+// OptionsOf returns the options passed with the request, nil if none are passed
+func OptionsOf(ctx *fasthttp.RequestCtx) *Options {
+	o, _ := ctx.UserValue(optionsKey).(*Options)
+	return o
+}
+
+// EncodeJSON writes v encoded by the JSONEncoder to w, o may be nil
+func (o *Options) EncodeJSON(w io.Writer, v interface{}) error {
+	if o == nil || o.JSONEncoder == nil {
+		return StdJSONEncoder(w, v)
+	}
+	return o.JSONEncoder(w, v)
+}
+
+// envelope returns the Envelope, o may be nil
+func (o *Options) envelope() *Envelope {
+	if o == nil {
+		return nil
+	}
+	return o.Envelope
+}
+
+// loadTemplates returns the templates with the functions, o may be nil
+func (o *Options) loadTemplates(funcs template.FuncMap) (*template.Template, error) {
+	if o == nil || !o.TemplateCache {
+		return template.New("").Funcs(funcs).ParseGlob("./template/*.htm")
+	}
+
+	o.templatesMu.Lock()
+	defer o.templatesMu.Unlock()
+	if o.templates == nil {
+		// functions are bound to the view when the templates are cloned
+		t, err := template.New("").Funcs(funcs).ParseGlob("./template/*.htm")
+		if err != nil {
+			return nil, err
+		}
+		o.templates = t
+	}
+
+	t, err := o.templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return t.Funcs(funcs), nil
+}
@@ -0,0 +1,165 @@
+package view
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Param is a path parameter of the matched route
+type Param struct {
+	Key   string
+	Value string
+
+	// value converted by the converter of the parameter, e.g. an int for {id:int},
+	// nil if the parameter has no converter
+	Typed interface{}
+}
+
+// Params are the path parameters of the matched route in path order
+type Params []Param
+
+// Get returns the value of the parameter, ok is false if the route has no such parameter
+func (ps Params) Get(name string) (value string, ok bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName returns the value of the parameter, empty if the route has no such parameter
+func (ps Params) ByName(name string) string {
+	v, _ := ps.Get(name)
+	return v
+}
+
+// SetParams is called by router to make the path parameters available to Param().
+// The params are only valid until the view returns.
+func (r *View) SetParams(ps Params) {
+	r.params = ps
+}
+
+// Params returns the path parameters of the matched route, e.g. {id} of "/articles/{id}"
+func (r *View) Params() Params {
+	return r.params
+}
+
+// Param returns the value of the path parameter, empty if the route has no such parameter:
+//     id := r.Param("id") // route "/articles/{id}"
+func (r *View) Param(name string) string {
+	return r.params.ByName(name)
+}
+
+// ParamValue returns the typed value of the path parameter if it has a converter,
+// e.g. an int for {id:int}, the string value otherwise, nil if the route has no such parameter
+func (r *View) ParamValue(name string) interface{} {
+	for _, p := range r.params {
+		if p.Key == name {
+			if p.Typed != nil {
+				return p.Typed
+			}
+			return p.Value
+		}
+	}
+	return nil
+}
+
+// ParamInt returns the path parameter as int, e.g. of {id:int} or {id:[0-9]+}
+func (r *View) ParamInt(name string) (int, error) {
+	if i, ok := r.ParamValue(name).(int); ok {
+		return i, nil
+	}
+	return strconv.Atoi(r.Param(name))
+}
+
+// BindParams sets the fields of the struct pointed to by dst to the path parameters
+// named by their `param` tags. Typed values of converters are assigned to fields of their
+// type, other fields are parsed from the string value:
+//     var p struct {
+//         ID   int       `param:"id"`   // {id:int}
+//         Day  time.Time `param:"day"`  // {day:date}
+//         Slug string    `param:"slug"` // {slug}
+//     }
+//     err := r.BindParams(&p)
+func (r *View) BindParams(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind params: %T is not a pointer to a struct", dst)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Tag.Get("param")
+		if name == "" || rt.Field(i).PkgPath != "" {
+			continue
+		}
+
+		var p *Param
+		for j := range r.params {
+			if r.params[j].Key == name {
+				p = &r.params[j]
+				break
+			}
+		}
+		if p == nil {
+			continue
+		}
+
+		if err := setParamField(rv.Field(i), p); err != nil {
+			return fmt.Errorf("bind param '%s' to field %s: %s", name, rt.Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setParamField(f reflect.Value, p *Param) error {
+	if p.Typed != nil {
+		tv := reflect.ValueOf(p.Typed)
+		if tv.Type().AssignableTo(f.Type()) {
+			f.Set(tv)
+			return nil
+		}
+	}
+
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(p.Value))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(p.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(p.Value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(p.Value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(p.Value, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(fl)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(p.Value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+
+	return nil
+}
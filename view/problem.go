@@ -0,0 +1,135 @@
+package view
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/valyala/bytebufferpool"
+	"github.com/valyala/fasthttp"
+)
+
+// ProblemContentType is the media type of problem details documents, see RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details document as defined by RFC 7807
+type Problem struct {
+	Type     string // uri identifying the problem type, "about:blank" if it's empty
+	Title    string // short summary of the problem type
+	Status   int
+	Detail   string // explanation of this occurrence of the problem
+	Instance string // uri of this occurrence, e.g. the request path
+
+	// Extensions are additional members of the document, e.g. "invalid-params"
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON encodes the problem with its extension members at the top level
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = "about:blank"
+	}
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// Problem returns the problem details of the error occurred at instance. The code of the
+// error is the "code" member, details which are a map are added as members, other
+// details are the "details" member.
+func (e *HTTPError) Problem(instance string) *Problem {
+	p := &Problem{
+		Type:       e.Type,
+		Title:      fasthttp.StatusMessage(e.Status),
+		Status:     e.Status,
+		Detail:     e.Message,
+		Instance:   instance,
+		Extensions: make(map[string]interface{}),
+	}
+	if p.Detail == p.Title {
+		p.Detail = ""
+	}
+
+	if e.Code != "" {
+		p.Extensions["code"] = e.Code
+	}
+	switch d := e.Details.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, v := range d {
+			p.Extensions[k] = v
+		}
+	default:
+		p.Extensions["details"] = d
+	}
+
+	return p
+}
+
+// WriteProblem replaces the response body by the problem details of the error,
+// the request path is the instance of the problem
+func WriteProblem(ctx *fasthttp.RequestCtx, e *HTTPError) {
+	opts := OptionsOf(ctx)
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := opts.EncodeJSON(buf, e.Problem(string(ctx.Path()))); err != nil {
+		// extensions can't be encoded
+		buf.Reset()
+		_ = opts.EncodeJSON(buf, &Problem{
+			Type:   e.Type,
+			Title:  fasthttp.StatusMessage(e.Status),
+			Status: e.Status,
+			Detail: e.Message,
+		})
+	}
+
+	ctx.Response.ResetBody()
+	ctx.SetStatusCode(e.Status)
+	ctx.SetContentType(ProblemContentType)
+	ctx.SetBody(buf.B)
+}
+
+// InvalidParam describes a request parameter failing validation
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem fails the view with the status, detail and extension members of the
+// problem details document, see Router.ProblemDetails:
+//     r.Problem(409, "the article was changed", map[string]interface{}{"version": 3})
+func (r *ApiView) Problem(status int, detail string, extensions map[string]interface{}) {
+	e := NewHTTPError(status, detail)
+	if len(extensions) > 0 {
+		e.Details = extensions
+	}
+	r.Fail(e)
+}
+
+// ValidationProblem fails the view with 422 Unprocessable Entity listing the reasons
+// of the invalid parameters by name in the "invalid-params" member
+func (r *ApiView) ValidationProblem(invalid map[string]string) {
+	params := make([]InvalidParam, 0, len(invalid))
+	for name, reason := range invalid {
+		params = append(params, InvalidParam{Name: name, Reason: reason})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+
+	r.Problem(fasthttp.StatusUnprocessableEntity, "the request parameters are invalid",
+		map[string]interface{}{"invalid-params": params})
+}
@@ -0,0 +1,146 @@
+package view
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ResourceIDParam is the default path parameter of the items of resources, e.g. {id} of "/articles/{id}"
+const ResourceIDParam = "id"
+
+// Lister lists the collection of a resource, GET /articles
+type Lister interface {
+	List()
+}
+
+// Retriever renders an item of a resource, GET /articles/{id}
+type Retriever interface {
+	Retrieve(id string)
+}
+
+// Creator creates an item of a resource and returns its id, POST /articles is answered
+// with 201 Created and the Location of the item if the id is not empty
+type Creator interface {
+	Create() (id string)
+}
+
+// Updater replaces an item of a resource, PUT /articles/{id}
+type Updater interface {
+	Update(id string)
+}
+
+// PartialUpdater changes fields of an item of a resource, PATCH /articles/{id}
+type PartialUpdater interface {
+	PartialUpdate(id string)
+}
+
+// Destroyer deletes an item of a resource, DELETE /articles/{id} is answered with 204 No Content
+type Destroyer interface {
+	Destroy(id string)
+}
+
+// ResourceView is a view of a REST resource implementing all actions, views registered as
+// resource could implement any of Lister, Retriever, Creator, Updater, PartialUpdater and
+// Destroyer instead:
+//     type Articles struct {
+//         view.ApiView
+//     }
+//
+//     func (a *Articles) List()              { a.SetBody(articles.All()) }
+//     func (a *Articles) Retrieve(id string) { a.SetBody(articles.Get(id)) }
+//
+//     r.Resource("/articles", &Articles{})
+type ResourceView interface {
+	MethodViewer
+	Lister
+	Retriever
+	Creator
+	Updater
+	PartialUpdater
+	Destroyer
+}
+
+// SwitchResource calls the action of the resource view for the request method, on the
+// collection or on the item with the id of the path parameter param, ResourceIDParam if
+// it's empty. It sets the status of created and deleted items unless the action did, Render
+// is skipped for deleted items. It returns the error the view failed with like Switcher,
+// actions the view doesn't implement fail with 405 Method Not Allowed.
+func SwitchResource(v MethodViewer, item bool, param string) error {
+	ctx := v.GetCtx()
+	defer v.After()
+
+	v.Before()
+	if err := viewErr(v); err != nil {
+		return err
+	}
+
+	if param == "" {
+		param = ResourceIDParam
+	}
+	id, _ := ctx.UserValue(param).(string)
+	implemented := true
+	switch method := string(ctx.Method()); {
+	case !item && method == fasthttp.MethodGet:
+		if a, ok := v.(Lister); ok {
+			a.List()
+		} else {
+			implemented = false
+		}
+	case !item && method == fasthttp.MethodPost:
+		if a, ok := v.(Creator); ok {
+			created := a.Create()
+			if viewErr(v) == nil && ctx.Response.StatusCode() == fasthttp.StatusOK {
+				ctx.SetStatusCode(fasthttp.StatusCreated)
+				if created != "" {
+					location := strings.TrimSuffix(string(ctx.Path()), "/") + "/" + url.PathEscape(created)
+					ctx.Response.Header.Set("Location", location)
+				}
+			}
+		} else {
+			implemented = false
+		}
+	case item && method == fasthttp.MethodGet:
+		if a, ok := v.(Retriever); ok {
+			a.Retrieve(id)
+		} else {
+			implemented = false
+		}
+	case item && method == fasthttp.MethodPut:
+		if a, ok := v.(Updater); ok {
+			a.Update(id)
+		} else {
+			implemented = false
+		}
+	case item && method == fasthttp.MethodPatch:
+		if a, ok := v.(PartialUpdater); ok {
+			a.PartialUpdate(id)
+		} else {
+			implemented = false
+		}
+	case item && method == fasthttp.MethodDelete:
+		if a, ok := v.(Destroyer); ok {
+			a.Destroy(id)
+			if err := viewErr(v); err != nil {
+				return err
+			}
+			if ctx.Response.StatusCode() == fasthttp.StatusOK {
+				ctx.SetStatusCode(fasthttp.StatusNoContent)
+			}
+			return nil
+		}
+		implemented = false
+	default:
+		implemented = false
+	}
+	if !implemented {
+		return NewHTTPError(fasthttp.StatusMethodNotAllowed, "")
+	}
+	if err := viewErr(v); err != nil {
+		return err
+	}
+
+	v.Render()
+	return viewErr(v)
+}
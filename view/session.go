@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"errors"
 	"github.com/google/uuid"
+	"sync"
 	"time"
 )
 
@@ -14,7 +15,44 @@ var (
 )
 
 // stored sessions
-var sessions = make(map[[16]byte]*Session)
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[[16]byte]*Session)
+)
+
+// life cycle of sessions created by NewDefaultSession, unit: minute
+var DefaultSessionLifeCycle int64 = 30
+
+// SessionStore creates sessions living for its life cycle, the router passes its store to
+// views, see View.Sessions:
+//     s, err := r.Sessions().New(username, userId)
+type SessionStore struct {
+	LifeCycle time.Duration // whole minutes
+}
+
+// NewSessionStore returns a store of sessions living for lifeCycle, it's truncated to minutes
+func NewSessionStore(lifeCycle time.Duration) *SessionStore {
+	return &SessionStore{LifeCycle: lifeCycle}
+}
+
+// New creates a session of the user living for the life cycle of the store
+func (st *SessionStore) New(username string, userId interface{}) (*Session, error) {
+	return NewSession(username, userId, int64(st.LifeCycle/time.Minute))
+}
+
+// SetSessionStore is called by router to make its session store available to Sessions()
+func (r *View) SetSessionStore(st *SessionStore) {
+	r.sessions = st
+}
+
+// Sessions returns the session store of the router, sessions live for
+// DefaultSessionLifeCycle if the router has no store
+func (r *View) Sessions() *SessionStore {
+	if r.sessions == nil {
+		return NewSessionStore(time.Duration(DefaultSessionLifeCycle) * time.Minute)
+	}
+	return r.sessions
+}
 
 type Session struct {
 	id        [16]byte               // session uuid
@@ -31,10 +69,7 @@ func (s *Session) updateTime() {
 }
 
 func (s *Session) isExpired() bool {
-	if s.expire.After(time.Now()) {
-		return true
-	}
-	return false
+	return !s.expire.After(time.Now())
 }
 
 // set session data
@@ -72,7 +107,8 @@ func CreateSessionId(username string, userId interface{}) ([16]byte, error) {
 	if err != nil {
 		return [16]byte{}, err
 	}
-	return uuid.Must(uuid.FromBytes(buf)), nil
+	buf = append(buf, b.Bytes()...)
+	return uuid.NewSHA1(uuid.Nil, buf), nil
 }
 
 func NewSession(username string, userId interface{}, lifeCycle int64) (*Session, error) {
@@ -88,6 +124,13 @@ func NewSession(username string, userId interface{}, lifeCycle int64) (*Session,
 	s.id = id
 
 	// add session to session map
+	sessionsMu.Lock()
 	sessions[id] = s
+	sessionsMu.Unlock()
 	return s, nil
 }
+
+// create new session with DefaultSessionLifeCycle
+func NewDefaultSession(username string, userId interface{}) (*Session, error) {
+	return NewSession(username, userId, DefaultSessionLifeCycle)
+}
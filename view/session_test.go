@@ -0,0 +1,69 @@
+package view
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionStore(t *testing.T) {
+	st := NewSessionStore(5 * time.Minute)
+
+	s, err := st.New("alice", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.lifeCycle != 5 {
+		t.Errorf("life cycle was %d minutes; want 5", s.lifeCycle)
+	}
+
+	s.Set("role", "admin")
+	if v, err := s.Get("role"); err != nil || v != "admin" {
+		t.Errorf("role was %v, %v; want admin", v, err)
+	}
+	if _, err := s.Get("missing"); err != SessionNoThisKey {
+		t.Errorf("error of a missing key was %v; want %v", err, SessionNoThisKey)
+	}
+
+	again, err := st.New("alice", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.id != s.id {
+		t.Errorf("ids of the same user differ, %x and %x", again.id, s.id)
+	}
+	other, err := st.New("alice", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.id == s.id {
+		t.Errorf("users with different ids share the session id %x", s.id)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	if _, err := NewSession("alice", func() {}, 5); err == nil {
+		t.Error("session of a user id which can't be encoded was created")
+	}
+
+	s, err := NewSessionStore(0).New("bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("role", "admin")
+	if _, err := s.Get("role"); err != SessionExpired {
+		t.Errorf("error of an expired session was %v; want %v", err, SessionExpired)
+	}
+}
+
+func TestViewSessions(t *testing.T) {
+	v := &View{}
+	if lc := v.Sessions().LifeCycle; lc != time.Duration(DefaultSessionLifeCycle)*time.Minute {
+		t.Errorf("life cycle without store was %s; want %d minutes", lc, DefaultSessionLifeCycle)
+	}
+
+	st := NewSessionStore(time.Hour)
+	v.SetSessionStore(st)
+	if v.Sessions() != st {
+		t.Error("store set by the router isn't used")
+	}
+}
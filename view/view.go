@@ -3,6 +3,7 @@ package view
 import (
 	"crypto/sha1"
 	"encoding/binary"
+	"fmt"
 	"github.com/valyala/fasthttp"
 	"github.com/xxxmailk/cera/log"
 	"golang.org/x/net/xsrftoken"
@@ -84,15 +85,23 @@ func newActionId() [16]byte {
 	return buf
 }
 
+// URLFunc generates url of a named route, see router.Router.URL
+type URLFunc func(name string, params ...interface{}) (string, error)
+
 type View struct {
-	Tpl    string                 // template name
-	Data   map[string]interface{} // stored user values
-	Ctx    *fasthttp.RequestCtx
-	Cookie *fasthttp.Cookie
-	Logger log.SimpleLogger
+	Tpl      string                 // template name
+	Data     map[string]interface{} // stored user values
+	Ctx      *fasthttp.RequestCtx
+	Cookie   *fasthttp.Cookie
+	Logger   log.SimpleLogger
+	urlFn    URLFunc
+	params   Params
+	err      error
+	sessions *SessionStore
 }
 
-// combine this struct and rewrite those functions to reply http methods
+// combine this struct and rewrite those functions to reply http methods,
+// methods which are not rewritten fail with 404 Not Found
 func (r *View) Init() {
 	r.Data = make(map[string]interface{})
 }
@@ -100,51 +109,35 @@ func (r *View) Init() {
 func (r *View) Before() {}
 
 func (r *View) Get() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with GET method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Head() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Head method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Options() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Option method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Post() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Post method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Put() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Put method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Patch() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Patch method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Delete() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Delete method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) Trace() {
-	if err := r.Html404(); err != nil {
-		r.Logger.Errorf("handle 404 with Trace method error %s", err)
-	}
+	r.Fail(NewHTTPError(fasthttp.StatusNotFound, ""))
 }
 
 func (r *View) SetLogger(l log.SimpleLogger) {
@@ -153,10 +146,41 @@ func (r *View) SetLogger(l log.SimpleLogger) {
 
 func (r *View) After() {}
 
+// SetURLFunc is called by router to make named routes available to URL() and templates
+func (r *View) SetURLFunc(fn URLFunc) {
+	r.urlFn = fn
+}
+
+// URL generates url of a named route, e.g. for redirects:
+//     url, err := r.URL("article", "id", 42)
+func (r *View) URL(name string, params ...interface{}) (string, error) {
+	if r.urlFn == nil {
+		return "", fmt.Errorf("no router to generate url of route '%s'", name)
+	}
+	return r.urlFn(name, params...)
+}
+
+// template functions available in all templates, e.g.:
+//     <a href="{{ url "article" "id" .ID }}">
+func (r *View) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"url": r.URL,
+	}
+}
+
+// loadTemplates returns the templates with the template functions of the view
+func (r *View) loadTemplates() (*template.Template, error) {
+	return OptionsOf(r.Ctx).loadTemplates(r.templateFuncs())
+}
+
 func (r *View) Render() {
-	t := template.Must(template.ParseGlob("./template/*.htm"))
+	t, err := r.loadTemplates()
+	if err != nil {
+		r.Fail(fmt.Errorf("load templates failed, %s", err))
+		return
+	}
 	r.Ctx.Response.Header.SetContentType("text/html; charset=utf-8")
-	err := t.ExecuteTemplate(r.Ctx.Response.BodyWriter(), r.Tpl, r.Data)
+	err = t.ExecuteTemplate(r.Ctx.Response.BodyWriter(), r.Tpl, r.Data)
 	if err != nil {
 		r.Logger.Errorf("render template failed, %s", err)
 		return
@@ -191,40 +215,54 @@ func (r *View) SetCtx(ctx *fasthttp.RequestCtx) {
 	r.Ctx = ctx
 }
 
-func Switcher(v MethodViewer) {
+// Switcher calls the methods of the view for the request method. It returns the error the
+// view failed with, see View.Fail, Render is skipped then. Methods unknown to views are
+// answered with 501 Not Implemented.
+func Switcher(v MethodViewer) error {
 	// running before method priority
 	ctx := v.GetCtx()
+	defer v.After()
+
 	v.Before()
+	if err := viewErr(v); err != nil {
+		return err
+	}
+
 	method := ctx.Method()
 	switch string(method) {
 	case fasthttp.MethodGet:
 		v.Get()
-		v.Render()
 	case fasthttp.MethodPost:
 		v.Post()
-		v.Render()
 	case fasthttp.MethodHead:
 		v.Head()
-		v.Render()
 	case fasthttp.MethodOptions:
 		v.Options()
-		v.Render()
 	case fasthttp.MethodPut:
 		v.Put()
-		v.Render()
 	case fasthttp.MethodPatch:
 		v.Patch()
-		v.Render()
 	case fasthttp.MethodDelete:
 		v.Delete()
-		v.Render()
 	case fasthttp.MethodTrace:
 		v.Trace()
-		v.Render()
 	default:
-		HtmlUnknownMethod(ctx)
+		return NewHTTPError(fasthttp.StatusNotImplemented, "")
 	}
-	v.After()
+	if err := viewErr(v); err != nil {
+		return err
+	}
+
+	v.Render()
+	return viewErr(v)
+}
+
+// viewErr returns the error the view failed with
+func viewErr(v MethodViewer) error {
+	if f, ok := v.(interface{ Err() error }); ok {
+		return f.Err()
+	}
+	return nil
 }
 
 func (r *View) GetPostArgs(key string) string {
@@ -282,14 +320,14 @@ func HtmlUnknownMethod(ctx *fasthttp.RequestCtx) error {
 	html := `
 <html>
 <head>
-<title>Page not found</title>
+<title>Not implemented</title>
 </head>
 <body style="background:#000;text-align:center;">
-<span style="font-size:5em;color:#fff;"><b>500, sorry! unknown http method :) </b></span>
+<span style="font-size:5em;color:#fff;"><b>501, sorry! unknown http method :) </b></span>
 </body>
 </html>
 `
-	ctx.SetStatusCode(500)
+	ctx.SetStatusCode(fasthttp.StatusNotImplemented)
 	if _, err := ctx.Write([]byte(html)); err != nil {
 		return err
 	}
@@ -304,8 +342,5 @@ func (r *View) Html404() error {
 `
 	r.Ctx.SetStatusCode(404)
 	r.Ctx.SetBodyString(html)
-	if _, err := r.Ctx.Write([]byte(html)); err != nil {
-		return err
-	}
 	return nil
 }